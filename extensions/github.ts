@@ -10,7 +10,7 @@ const manifest = {
     {
       name: "token",
       title: "Personal Access Token",
-      type: "string",
+      type: "secret",
     },
   ],
   commands: [
@@ -8,7 +8,7 @@ const manifest = {
     {
       name: "token",
       title: "Raindrop API Token",
-      type: "string",
+      type: "secret",
     },
   ],
   commands: [
@@ -9,7 +9,7 @@ const manifest = {
     {
       name: "token",
       title: "Access Token",
-      type: "string",
+      type: "secret",
     },
   ],
   commands: [
@@ -16,6 +16,7 @@ require (
 	github.com/muesli/termenv v0.15.2
 	github.com/santhosh-tekuri/jsonschema/v5 v5.3.1
 	github.com/spf13/cobra v1.8.0
+	golang.org/x/crypto v0.16.0
 	golang.org/x/term v0.15.0
 )
 
@@ -0,0 +1,70 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/pomdtr/sunbeam/internal/config"
+	"github.com/pomdtr/sunbeam/internal/extensions"
+	"github.com/pomdtr/sunbeam/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+func NewCmdCache(cfg config.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "cache",
+		Short:   "Manage the cached outputs of the extensions",
+		GroupID: CommandGroupCore,
+	}
+
+	cmd.AddCommand(NewCmdCacheClear(cfg))
+	return cmd
+}
+
+func NewCmdCacheClear(cfg config.Config) *cobra.Command {
+	return &cobra.Command{
+		Use:   "clear [alias]",
+		Short: "Clear the cached outputs of an extension, or of all extensions",
+		Args:  cobra.MaximumNArgs(1),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) > 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+
+			return cfg.Aliases(), cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				cacheDirs, err := filepath.Glob(filepath.Join(utils.CacheDir(), "extensions", "*", "cache"))
+				if err != nil {
+					return err
+				}
+
+				for _, cacheDir := range cacheDirs {
+					if err := os.RemoveAll(cacheDir); err != nil {
+						return fmt.Errorf("failed to clear cache: %w", err)
+					}
+				}
+
+				return nil
+			}
+
+			extensionConfig, ok := cfg.Extensions[args[0]]
+			if !ok {
+				return fmt.Errorf("extension %s not found", args[0])
+			}
+
+			extension, err := extensions.LoadExtension(extensionConfig.Origin)
+			if err != nil {
+				return fmt.Errorf("failed to load extension %s: %w", args[0], err)
+			}
+
+			if err := os.RemoveAll(extension.CacheDir()); err != nil {
+				return fmt.Errorf("failed to clear cache: %w", err)
+			}
+
+			return nil
+		},
+	}
+}
@@ -0,0 +1,50 @@
+package cli
+
+import (
+	"os"
+
+	"github.com/cli/go-gh/v2/pkg/tableprinter"
+	"github.com/mattn/go-isatty"
+	"github.com/pomdtr/sunbeam/internal/config"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+func NewCmdConfig(cfg config.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "config",
+		Short:   "Inspect the sunbeam config",
+		GroupID: CommandGroupCore,
+	}
+
+	cmd.AddCommand(NewCmdConfigSources(cfg))
+	return cmd
+}
+
+func NewCmdConfigSources(cfg config.Config) *cobra.Command {
+	return &cobra.Command{
+		Use:   "sources",
+		Short: "Show the config file each entry comes from",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var t tableprinter.TablePrinter
+			if isatty.IsTerminal(os.Stdout.Fd()) {
+				w, _, err := term.GetSize(int(os.Stdout.Fd()))
+				if err != nil {
+					return err
+				}
+				t = tableprinter.New(os.Stdout, true, w)
+			} else {
+				t = tableprinter.New(os.Stdout, false, 0)
+			}
+
+			for _, source := range cfg.Sources() {
+				t.AddField(source.Entry)
+				t.AddField(source.Path)
+				t.EndRow()
+			}
+
+			return t.Render()
+		},
+	}
+}
@@ -2,11 +2,14 @@ package cli
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"sort"
+	"time"
 
 	"github.com/mattn/go-isatty"
 	"github.com/pomdtr/sunbeam/internal/config"
@@ -35,7 +38,8 @@ func NewCmdCustom(alias string, extension extensions.Extension, extensionConfig
 			}
 
 			if len(inputBytes) == 0 {
-				if !isatty.IsTerminal(os.Stdout.Fd()) {
+				// the manifest is only printed when there is no terminal to render on
+				if !tui.HasTTY() {
 					encoder := json.NewEncoder(os.Stdout)
 					encoder.SetIndent("", "  ")
 					encoder.SetEscapeHTML(false)
@@ -53,7 +57,7 @@ func NewCmdCustom(alias string, extension extensions.Extension, extensionConfig
 				}
 
 				rootList := tui.NewRootList(extension.Manifest.Title, history, func() (config.Config, []sunbeam.ListItem, error) {
-					cfg, err := config.Load(config.Path)
+					cfg, err := config.Load(config.Path, config.Layers...)
 					if err != nil {
 						return config.Config{}, nil, err
 					}
@@ -73,7 +77,7 @@ func NewCmdCustom(alias string, extension extensions.Extension, extensionConfig
 				input.Preferences = extensionConfig.Preferences
 			}
 
-			return runExtension(extension, input)
+			return runExtension(extension, input, false)
 		},
 	}
 
@@ -106,12 +110,22 @@ func NewSubCmdCustom(alias string, extension extensions.Extension, extensionConf
 				}
 
 				switch param.Type {
-				case sunbeam.InputString:
+				case sunbeam.InputString, sunbeam.InputSecret:
 					value, err := cmd.Flags().GetString(param.Name)
 					if err != nil {
 						return err
 					}
 					params[param.Name] = value
+				case sunbeam.InputSelect:
+					value, err := cmd.Flags().GetString(param.Name)
+					if err != nil {
+						return err
+					}
+
+					if param.OptionsCommand == "" && !isValidOption(param.Options, value) {
+						return fmt.Errorf("invalid value for --%s: %s", param.Name, value)
+					}
+					params[param.Name] = value
 				case sunbeam.InputBoolean:
 					value, err := cmd.Flags().GetBool(param.Name)
 					if err != nil {
@@ -125,6 +139,10 @@ func NewSubCmdCustom(alias string, extension extensions.Extension, extensionConf
 					}
 					params[param.Name] = value
 				}
+
+				if err := param.Validate(params[param.Name]); err != nil {
+					return fmt.Errorf("invalid value for --%s: %w", param.Name, err)
+				}
 			}
 
 			preferences := extensionConfig.Preferences
@@ -156,18 +174,34 @@ func NewSubCmdCustom(alias string, extension extensions.Extension, extensionConf
 				input.Query = string(bytes.Trim(stdin, "\n"))
 			}
 
-			return runExtension(extension, input)
+			return runExtension(extension, input, true)
 		},
 	}
 
 	for _, input := range command.Params {
 		switch input.Type {
-		case sunbeam.InputString:
+		case sunbeam.InputString, sunbeam.InputSecret:
 			cmd.Flags().String(input.Name, "", input.Title)
 		case sunbeam.InputBoolean:
 			cmd.Flags().Bool(input.Name, false, input.Title)
 		case sunbeam.InputNumber:
 			cmd.Flags().Int(input.Name, 0, input.Title)
+		case sunbeam.InputSelect:
+			input := input
+			cmd.Flags().String(input.Name, "", input.Title)
+			_ = cmd.RegisterFlagCompletionFunc(input.Name, func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+				options, err := extension.Options(input, extensionConfig.Preferences)
+				if err != nil {
+					return nil, cobra.ShellCompDirectiveError
+				}
+
+				completions := make([]string, 0, len(options))
+				for _, option := range options {
+					completions = append(completions, fmt.Sprintf("%s\t%s", option.Value, option.Title))
+				}
+
+				return completions, cobra.ShellCompDirectiveNoFileComp
+			})
 		}
 
 		if !input.Optional {
@@ -178,23 +212,74 @@ func NewSubCmdCustom(alias string, extension extensions.Extension, extensionConf
 	return cmd
 }
 
-func runExtension(extension extensions.Extension, input sunbeam.Payload) error {
+// isValidOption only checks the static options, dynamic options are not known in advance
+func isValidOption(options []sunbeam.InputOption, value string) bool {
+	if len(options) == 0 {
+		return true
+	}
+
+	for _, option := range options {
+		if option.Value == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// runExtension runs the command of the payload. When stdout is redirected,
+// the output of the command is written to it, unless tty is set: views are
+// then rendered on the terminal, so that print actions can write to stdout.
+func runExtension(extension extensions.Extension, input sunbeam.Payload, tty bool) error {
 	command, ok := extension.Command(input.Command)
 	if !ok {
 		return fmt.Errorf("command %s not found", input.Command)
 	}
 
-	if !isatty.IsTerminal(os.Stdout.Fd()) {
-		cmd, err := extension.Cmd(input)
+	// the views use the resolved preferences for their exec actions too
+	preferences, err := extension.ResolvePreferences(input.Preferences)
+	if err != nil {
+		return err
+	}
+	input.Preferences = preferences
+
+	// tty commands write to stdout themselves, and read from the terminal
+	isView := command.Mode == sunbeam.CommandModeSearch || command.Mode == sunbeam.CommandModeFilter || command.Mode == sunbeam.CommandModeDetail
+	if command.Mode != sunbeam.CommandModeTTY && !isatty.IsTerminal(os.Stdout.Fd()) && !(tty && isView && tui.HasTTY()) {
+		// persistent and http extensions are not run as a separate process
+		if !extension.OneShot() {
+			output, err := extension.Output(input)
+			if err != nil {
+				return err
+			}
+
+			_, err = os.Stdout.Write(output)
+			return err
+		}
+
+		timeout := extension.Timeout(input.Command)
+		ctx, cancel := extensions.WithTimeout(context.Background(), timeout)
+		defer cancel()
+
+		// the command runs in its own process group, so that its children are
+		// stopped with it. stdin is already consumed, unless it is the
+		// terminal, which the group can not read from.
+		cmd, err := extension.CmdContext(ctx, input)
 		if err != nil {
 			return err
 		}
 
-		cmd.Stdin = os.Stdin
+		var stderr bytes.Buffer
 		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
+		cmd.Stderr = io.MultiWriter(os.Stderr, &stderr)
 
-		return cmd.Run()
+		start := time.Now()
+		err = cmd.Run()
+		if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			err = extensions.TimeoutError{Timeout: timeout}
+		}
+		extension.Log(input, start, stderr.String(), err)
+		return err
 	}
 
 	switch command.Mode {
@@ -204,7 +289,11 @@ func runExtension(extension extensions.Extension, input sunbeam.Payload) error {
 	case sunbeam.CommandModeSilent:
 		return extension.Run(input)
 	case sunbeam.CommandModeTTY:
-		cmd, err := extension.Cmd(input)
+		timeout := extension.Timeout(input.Command)
+		ctx, cancel := extensions.WithTimeout(context.Background(), timeout)
+		defer cancel()
+
+		cmd, err := extension.InteractiveCmdContext(ctx, input)
 		if err != nil {
 			return err
 		}
@@ -213,7 +302,14 @@ func runExtension(extension extensions.Extension, input sunbeam.Payload) error {
 		cmd.Stdout = os.Stdout
 		cmd.Stderr = os.Stderr
 
-		return cmd.Run()
+		start := time.Now()
+		err = cmd.Run()
+		if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			err = extensions.TimeoutError{Timeout: timeout}
+		}
+		extension.Log(input, start, "", err)
+
+		return err
 	default:
 		return fmt.Errorf("unknown command mode: %s", command.Mode)
 	}
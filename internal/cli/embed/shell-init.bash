@@ -0,0 +1,11 @@
+# sunbeam key bindings for bash, load them with: eval "$(sunbeam shell-init bash)"
+__sunbeam_widget() {
+  local selected
+  selected="$({{ .Command }})" || return
+  READLINE_LINE="${READLINE_LINE:0:$READLINE_POINT}${selected}${READLINE_LINE:$READLINE_POINT}"
+  READLINE_POINT=$((READLINE_POINT + ${#selected}))
+}
+
+bind -m emacs-standard -x '"\C-@": __sunbeam_widget'
+bind -m vi-command -x '"\C-@": __sunbeam_widget'
+bind -m vi-insert -x '"\C-@": __sunbeam_widget'
@@ -0,0 +1,13 @@
+# sunbeam key bindings for fish, load them with: sunbeam shell-init fish | source
+function sunbeam-widget
+    set -l selected ({{ .Command }} | string collect)
+    if test -n "$selected"
+        commandline --insert -- $selected
+    end
+    commandline --function repaint
+end
+
+bind -k nul sunbeam-widget
+if bind -M insert >/dev/null 2>&1
+    bind -M insert -k nul sunbeam-widget
+end
@@ -1,8 +1,10 @@
 package cli
 
 import (
+	"bufio"
 	_ "embed"
 	"fmt"
+	"io"
 	"net/url"
 	"os"
 	"os/exec"
@@ -351,7 +353,11 @@ func NewCmdExtensionList(cfg config.Config) *cobra.Command {
 }
 
 func NewCmdExtensionRemove(cfg config.Config) *cobra.Command {
-	return &cobra.Command{
+	var flags struct {
+		purge bool
+	}
+
+	cmd := &cobra.Command{
 		Use:     "remove <alias>",
 		Short:   "Remove sunbeam extensions",
 		Aliases: []string{"rm", "uninstall"},
@@ -371,7 +377,11 @@ func NewCmdExtensionRemove(cfg config.Config) *cobra.Command {
 			return completions, cobra.ShellCompDirectiveNoFileComp
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
+			removed := make(map[string]config.ExtensionConfig)
 			for _, arg := range args {
+				if extensionConfig, ok := cfg.Extensions[arg]; ok {
+					removed[arg] = extensionConfig
+				}
 				delete(cfg.Extensions, arg)
 			}
 
@@ -379,6 +389,12 @@ func NewCmdExtensionRemove(cfg config.Config) *cobra.Command {
 				return fmt.Errorf("failed to save config: %w", err)
 			}
 
+			for alias, extensionConfig := range removed {
+				if err := purgeData(cmd, cfg, alias, extensionConfig.Origin, flags.purge); err != nil {
+					return err
+				}
+			}
+
 			if len(args) == 1 {
 				cmd.Printf("✅ Removed %s\n", args[0])
 				return nil
@@ -388,6 +404,51 @@ func NewCmdExtensionRemove(cfg config.Config) *cobra.Command {
 			return nil
 		},
 	}
+
+	cmd.Flags().BoolVar(&flags.purge, "purge", false, "delete the data of the extensions without asking")
+	return cmd
+}
+
+// purgeData deletes the data directory of a removed extension. Unless purge
+// is set, the user is asked for confirmation when stdin is a terminal.
+func purgeData(cmd *cobra.Command, cfg config.Config, alias string, origin string, purge bool) error {
+	dataDir, err := extensions.DataDir(origin)
+	if err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(dataDir); os.IsNotExist(err) {
+		return nil
+	}
+
+	// the data is shared by the aliases of the same origin
+	for _, extensionConfig := range cfg.Extensions {
+		if other, err := extensions.DataDir(extensionConfig.Origin); err == nil && other == dataDir {
+			return nil
+		}
+	}
+
+	if !purge {
+		if !isatty.IsTerminal(os.Stdin.Fd()) {
+			return nil
+		}
+
+		cmd.Printf("Purge the data of %s (%s)? [y/N] ", alias, dataDir)
+		answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && err != io.EOF {
+			return err
+		}
+
+		if answer = strings.ToLower(strings.TrimSpace(answer)); answer != "y" && answer != "yes" {
+			return nil
+		}
+	}
+
+	if err := os.RemoveAll(dataDir); err != nil {
+		return fmt.Errorf("failed to purge data of %s: %w", alias, err)
+	}
+
+	return nil
 }
 
 func NewCmdExtensionConfigure(cfg config.Config) *cobra.Command {
@@ -412,16 +473,44 @@ func NewCmdExtensionConfigure(cfg config.Config) *cobra.Command {
 				return fmt.Errorf("extension %s has no preferences", args[0])
 			}
 
+			preferences := make(map[string]any)
+			for name, value := range extensionConfig.Preferences {
+				preferences[name] = value
+			}
+
+			if err := extension.LoadSecrets(preferences); err != nil {
+				return err
+			}
+
 			var inputs []sunbeam.Input
 			for _, input := range extension.Manifest.Preferences {
-				if preference := extensionConfig.Preferences[input.Name]; preference != nil {
+				// references are edited in the config file
+				if extensions.IsPreferenceReference(preferences[input.Name]) {
+					continue
+				}
+
+				if preference := preferences[input.Name]; preference != nil {
 					input.Default = preference
 				}
 				input.Optional = false
 				inputs = append(inputs, input)
 			}
 
+			if len(inputs) == 0 {
+				return fmt.Errorf("the preferences of %s are references, edit them in the config file", args[0])
+			}
+
 			form := tui.NewForm(func(m map[string]any) tea.Msg {
+				m, err := extension.SaveSecrets(m)
+				if err != nil {
+					return err
+				}
+
+				for name, value := range extensionConfig.Preferences {
+					if extensions.IsPreferenceReference(value) {
+						m[name] = value
+					}
+				}
 				extensionConfig.Preferences = m
 				cfg.Extensions[args[0]] = extensionConfig
 				if err := cfg.Save(); err != nil {
@@ -430,6 +519,9 @@ func NewCmdExtensionConfigure(cfg config.Config) *cobra.Command {
 
 				return tui.ExitMsg{}
 			}, inputs...)
+			form.SetOptionsLoader(func(input sunbeam.Input) ([]sunbeam.InputOption, error) {
+				return extension.Options(input, extensionConfig.Preferences)
+			})
 
 			return tui.Draw(form)
 		},
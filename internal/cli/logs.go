@@ -0,0 +1,174 @@
+package cli
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pomdtr/sunbeam/internal/config"
+	"github.com/pomdtr/sunbeam/internal/extensions"
+	"github.com/spf13/cobra"
+)
+
+func NewCmdLogs(cfg config.Config) *cobra.Command {
+	var flags struct {
+		follow bool
+	}
+
+	cmd := &cobra.Command{
+		Use:     "logs [alias]",
+		Short:   "Print the logs of the extensions",
+		GroupID: CommandGroupCore,
+		Args:    cobra.MaximumNArgs(1),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) > 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+
+			return cfg.Aliases(), cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			aliases := cfg.Aliases()
+			if len(args) > 0 {
+				if _, ok := cfg.Extensions[args[0]]; !ok {
+					return fmt.Errorf("extension %s not found", args[0])
+				}
+				aliases = args
+			}
+
+			logPaths := make(map[string]string)
+			for _, alias := range aliases {
+				extension, err := extensions.LoadExtension(cfg.Extensions[alias].Origin)
+				if err != nil {
+					if len(args) > 0 {
+						return fmt.Errorf("failed to load extension %s: %w", alias, err)
+					}
+					continue
+				}
+
+				logPaths[alias] = extension.LogPath()
+			}
+
+			type aliasEntry struct {
+				alias string
+				entry extensions.LogEntry
+			}
+
+			var entries []aliasEntry
+			offsets := make(map[string]int64)
+			for alias, logPath := range logPaths {
+				logs, err := extensions.ReadLogs(logPath)
+				if err != nil {
+					return err
+				}
+
+				for _, entry := range logs {
+					entries = append(entries, aliasEntry{alias: alias, entry: entry})
+				}
+
+				if info, err := os.Stat(logPath); err == nil {
+					offsets[alias] = info.Size()
+				}
+			}
+
+			sort.SliceStable(entries, func(i, j int) bool {
+				return entries[i].entry.Time.Before(entries[j].entry.Time)
+			})
+
+			for _, entry := range entries {
+				printLogEntry(cmd.OutOrStdout(), entry.alias, entry.entry)
+			}
+
+			if !flags.follow {
+				return nil
+			}
+
+			for {
+				time.Sleep(500 * time.Millisecond)
+				for alias, logPath := range logPaths {
+					offset, err := followLog(logPath, offsets[alias], func(entry extensions.LogEntry) {
+						printLogEntry(cmd.OutOrStdout(), alias, entry)
+					})
+					if err != nil {
+						return err
+					}
+					offsets[alias] = offset
+				}
+			}
+		},
+	}
+
+	cmd.Flags().BoolVarP(&flags.follow, "follow", "f", false, "keep printing new entries")
+	return cmd
+}
+
+// followLog reads the entries appended to the log since offset, and returns
+// the new offset. If the log was rotated, it is read from the start.
+func followLog(logPath string, offset int64, handler func(extensions.LogEntry)) (int64, error) {
+	info, err := os.Stat(logPath)
+	if os.IsNotExist(err) {
+		return 0, nil
+	} else if err != nil {
+		return offset, err
+	}
+
+	if info.Size() < offset {
+		offset = 0
+	}
+
+	if info.Size() == offset {
+		return offset, nil
+	}
+
+	f, err := os.Open(logPath)
+	if err != nil {
+		return offset, err
+	}
+	defer f.Close()
+
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return offset, err
+	}
+
+	reader := bufio.NewReader(f)
+	for {
+		line, err := reader.ReadBytes('\n')
+		// incomplete lines are read on the next call
+		if err == io.EOF {
+			return offset, nil
+		} else if err != nil {
+			return offset, err
+		}
+		offset += int64(len(line))
+
+		var entry extensions.LogEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			continue
+		}
+
+		handler(entry)
+	}
+}
+
+func printLogEntry(w io.Writer, alias string, entry extensions.LogEntry) {
+	status := fmt.Sprintf("exit %d", entry.ExitCode)
+	if entry.ExitCode == -1 {
+		status = "error"
+	}
+
+	fmt.Fprintf(w, "%s [%s] %s · %s · %s\n", entry.Time.Format(time.DateTime), alias, entry.Command, status, entry.Duration.Round(time.Millisecond))
+	if entry.Error != "" && entry.Stderr == "" {
+		fmt.Fprintf(w, "  %s\n", entry.Error)
+	}
+
+	for _, line := range strings.Split(strings.TrimRight(entry.Stderr, "\n"), "\n") {
+		if line != "" {
+			fmt.Fprintf(w, "  %s\n", line)
+		}
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/MakeNowJust/heredoc"
 	"github.com/mattn/go-isatty"
@@ -57,6 +58,7 @@ See https://pomdtr.github.io/sunbeam for more information.`,
 	rootCmd.AddCommand(NewCmdCopy())
 	rootCmd.AddCommand(NewCmdPaste())
 	rootCmd.AddCommand(NewCmdOpen())
+	rootCmd.AddCommand(NewCmdShellInit())
 
 	docCmd := &cobra.Command{
 		Use:    "docs",
@@ -111,6 +113,8 @@ See https://pomdtr.github.io/sunbeam for more information.`,
 	rootCmd.AddCommand(versionCmd)
 
 	if IsSunbeamRunning() {
+		// extensions can only access their own storage
+		rootCmd.AddCommand(NewCmdStorage(config.Config{}))
 		return rootCmd, nil
 	}
 
@@ -133,11 +137,21 @@ See https://pomdtr.github.io/sunbeam for more information.`,
 		}
 	}
 
-	cfg, err := config.Load(config.Path)
+	cfg, err := config.Load(config.Path, config.Layers...)
 	if err != nil {
 		return nil, err
 	}
+
+	if err := extensions.MigrateDirs(cfg); err != nil {
+		return nil, err
+	}
+	extensions.DefaultTimeout = time.Duration(cfg.Timeout) * time.Second
 	rootCmd.AddCommand(NewCmdExtension(cfg))
+	rootCmd.AddCommand(NewCmdCache(cfg))
+	rootCmd.AddCommand(NewCmdLogs(cfg))
+	rootCmd.AddCommand(NewCmdStorage(cfg))
+	rootCmd.AddCommand(NewCmdSecrets(cfg))
+	rootCmd.AddCommand(NewCmdConfig(cfg))
 
 	extensionMap := make(map[string]extensions.Extension)
 	for alias, extensionConfig := range cfg.Extensions {
@@ -155,8 +169,15 @@ See https://pomdtr.github.io/sunbeam for more information.`,
 		rootCmd.AddCommand(command)
 	}
 
+	var flags struct {
+		tty    bool
+		insert bool
+	}
+
+	rootCmd.Flags().BoolVar(&flags.tty, "tty", false, "render on the terminal when stdout is redirected, instead of printing the config")
+	rootCmd.Flags().BoolVar(&flags.insert, "insert", false, "print the text of copy actions instead of copying it to the clipboard")
 	rootCmd.RunE = func(cmd *cobra.Command, args []string) error {
-		if !isatty.IsTerminal(os.Stdout.Fd()) {
+		if !isatty.IsTerminal(os.Stdout.Fd()) && !(flags.tty && tui.HasTTY()) {
 			encoder := json.NewEncoder(os.Stdout)
 			encoder.SetIndent("", "  ")
 			encoder.SetEscapeHTML(false)
@@ -169,7 +190,7 @@ See https://pomdtr.github.io/sunbeam for more information.`,
 		}
 
 		rootList := tui.NewRootList("Sunbeam", history, func() (config.Config, []sunbeam.ListItem, error) {
-			cfg, err := config.Load(config.Path)
+			cfg, err := config.Load(config.Path, config.Layers...)
 			if err != nil {
 				return config.Config{}, nil, err
 			}
@@ -189,6 +210,7 @@ See https://pomdtr.github.io/sunbeam for more information.`,
 
 			return cfg, items, nil
 		})
+		rootList.SetInsert(flags.insert)
 		return tui.Draw(rootList)
 
 	}
@@ -247,6 +269,8 @@ func onelinerListItems(oneliners []config.Oneliner) []sunbeam.ListItem {
 						Interactive: oneliner.Interactive,
 						Dir:         oneliner.Cwd,
 						Exit:        oneliner.Exit,
+						Env:         oneliner.Env,
+						Stdin:       oneliner.Stdin,
 					},
 				},
 				{
@@ -310,7 +334,7 @@ func extensionListItems(alias string, extension extensions.Extension, extensionC
 					Reload: true,
 				},
 			})
-		} else {
+		} else if extension.Type != extensions.ExtensionTypeHttp {
 			item.Actions = append(item.Actions, sunbeam.Action{
 				Title: "View Source",
 				Key:   "c",
@@ -0,0 +1,232 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/cli/go-gh/v2/pkg/tableprinter"
+	"github.com/mattn/go-isatty"
+	"github.com/pomdtr/sunbeam/internal/config"
+	"github.com/pomdtr/sunbeam/internal/extensions"
+	"github.com/pomdtr/sunbeam/pkg/sunbeam"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+func NewCmdSecrets(cfg config.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "secrets",
+		Short:   "Manage the secret preferences of the extensions",
+		GroupID: CommandGroupCore,
+	}
+
+	cmd.AddCommand(NewCmdSecretsList(cfg))
+	cmd.AddCommand(NewCmdSecretsRotate(cfg))
+	cmd.AddCommand(NewCmdSecretsDelete(cfg))
+	return cmd
+}
+
+func NewCmdSecretsList(cfg config.Config) *cobra.Command {
+	return &cobra.Command{
+		Use:     "list [alias]",
+		Short:   "List the stored secrets, without their values",
+		Aliases: []string{"ls"},
+		Args:    cobra.MaximumNArgs(1),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) > 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+
+			return cfg.Aliases(), cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			aliases := cfg.Aliases()
+			if len(args) > 0 {
+				aliases = args
+			}
+			sort.Strings(aliases)
+
+			var t tableprinter.TablePrinter
+			if isatty.IsTerminal(os.Stdout.Fd()) {
+				w, _, err := term.GetSize(int(os.Stdout.Fd()))
+				if err != nil {
+					return err
+				}
+				t = tableprinter.New(os.Stdout, true, w)
+			} else {
+				t = tableprinter.New(os.Stdout, false, 0)
+			}
+
+			for _, alias := range aliases {
+				extension, err := loadAlias(cfg, alias)
+				if err != nil {
+					if len(args) > 0 {
+						return err
+					}
+					continue
+				}
+
+				names, err := extension.Secrets().Names()
+				if err != nil {
+					return err
+				}
+
+				for _, name := range names {
+					t.AddField(alias)
+					t.AddField(name)
+					t.EndRow()
+				}
+			}
+
+			return t.Render()
+		},
+	}
+}
+
+func NewCmdSecretsRotate(cfg config.Config) *cobra.Command {
+	return &cobra.Command{
+		Use:               "rotate <alias> <name>",
+		Short:             "Replace the value of a secret, reading it from stdin",
+		Aliases:           []string{"set"},
+		Args:              cobra.ExactArgs(2),
+		ValidArgsFunction: completeSecrets(cfg, true),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			extension, err := loadAlias(cfg, args[0])
+			if err != nil {
+				return err
+			}
+
+			if !isSecretPreference(extension, args[1]) {
+				return fmt.Errorf("extension %s has no secret preference %s", args[0], args[1])
+			}
+
+			var value string
+			if isatty.IsTerminal(os.Stdin.Fd()) {
+				fmt.Fprintf(os.Stderr, "New value for %s: ", args[1])
+				bts, err := term.ReadPassword(int(os.Stdin.Fd()))
+				fmt.Fprintln(os.Stderr)
+				if err != nil {
+					return fmt.Errorf("failed to read value: %w", err)
+				}
+				value = string(bts)
+			} else {
+				bts, err := io.ReadAll(os.Stdin)
+				if err != nil {
+					return fmt.Errorf("failed to read stdin: %w", err)
+				}
+				value = strings.TrimSuffix(string(bts), "\n")
+			}
+
+			if value == "" {
+				return fmt.Errorf("empty value, use sunbeam secrets delete to remove a secret")
+			}
+
+			if err := extension.Secrets().Set(args[1], value); err != nil {
+				return err
+			}
+
+			// a plain text value left in the config would take precedence
+			if extensionConfig := cfg.Extensions[args[0]]; extensionConfig.Preferences[args[1]] != nil {
+				delete(extensionConfig.Preferences, args[1])
+				if err := cfg.Save(); err != nil {
+					return fmt.Errorf("failed to save config: %w", err)
+				}
+			}
+
+			cmd.Printf("✅ Rotated %s\n", args[1])
+			return nil
+		},
+	}
+}
+
+func NewCmdSecretsDelete(cfg config.Config) *cobra.Command {
+	return &cobra.Command{
+		Use:               "delete <alias> <name>",
+		Short:             "Delete a secret",
+		Aliases:           []string{"rm"},
+		Args:              cobra.ExactArgs(2),
+		ValidArgsFunction: completeSecrets(cfg, false),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			extension, err := loadAlias(cfg, args[0])
+			if err != nil {
+				return err
+			}
+
+			ok, err := extension.Secrets().Delete(args[1])
+			if err != nil {
+				return err
+			}
+
+			if !ok {
+				return fmt.Errorf("secret %s not found", args[1])
+			}
+
+			cmd.Printf("✅ Deleted %s\n", args[1])
+			return nil
+		},
+	}
+}
+
+func loadAlias(cfg config.Config, alias string) (extensions.Extension, error) {
+	extensionConfig, ok := cfg.Extensions[alias]
+	if !ok {
+		return extensions.Extension{}, fmt.Errorf("extension %s not found", alias)
+	}
+
+	extension, err := extensions.LoadExtension(extensionConfig.Origin)
+	if err != nil {
+		return extensions.Extension{}, fmt.Errorf("failed to load extension %s: %w", alias, err)
+	}
+
+	return extension, nil
+}
+
+func isSecretPreference(extension extensions.Extension, name string) bool {
+	for _, preference := range extension.Manifest.Preferences {
+		if preference.Name == name && preference.Type == sunbeam.InputSecret {
+			return true
+		}
+	}
+
+	return false
+}
+
+// completeSecrets completes the alias, then the secret preferences of the
+// extension, or only the stored ones.
+func completeSecrets(cfg config.Config, all bool) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) == 0 {
+			return cfg.Aliases(), cobra.ShellCompDirectiveNoFileComp
+		}
+
+		if len(args) > 1 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		extension, err := loadAlias(cfg, args[0])
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+
+		if !all {
+			names, err := extension.Secrets().Names()
+			if err != nil {
+				return nil, cobra.ShellCompDirectiveError
+			}
+
+			return names, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		var names []string
+		for _, preference := range extension.Manifest.Preferences {
+			if preference.Type == sunbeam.InputSecret {
+				names = append(names, preference.Name)
+			}
+		}
+
+		return names, cobra.ShellCompDirectiveNoFileComp
+	}
+}
@@ -0,0 +1,83 @@
+package cli
+
+import (
+	_ "embed"
+	"fmt"
+	"text/template"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+)
+
+//go:embed embed/shell-init.bash
+var bashInitScript string
+
+//go:embed embed/shell-init.zsh
+var zshInitScript string
+
+//go:embed embed/shell-init.fish
+var fishInitScript string
+
+var shellInitScripts = map[string]string{
+	"bash": bashInitScript,
+	"zsh":  zshInitScript,
+	"fish": fishInitScript,
+}
+
+func NewCmdShellInit() *cobra.Command {
+	var flags struct {
+		insert bool
+	}
+
+	cmd := &cobra.Command{
+		Use:   "shell-init <shell>",
+		Short: "Print the key bindings for the given shell",
+		Long: heredoc.Doc(`
+			Print the key bindings for the given shell.
+
+			The widget is bound to ctrl+space, the text printed by the selected action is inserted in the command line.
+			Use --insert to insert the text of copy actions too, instead of writing it to the clipboard.
+		`),
+		Example: heredoc.Doc(`
+			# bash (~/.bashrc)
+			eval "$(sunbeam shell-init bash)"
+
+			# zsh (~/.zshrc)
+			eval "$(sunbeam shell-init zsh)"
+
+			# fish (~/.config/fish/config.fish)
+			sunbeam shell-init fish | source
+		`),
+		GroupID:   CommandGroupCore,
+		Args:      cobra.ExactArgs(1),
+		ValidArgs: []string{"bash", "zsh", "fish"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			script, ok := shellInitScripts[args[0]]
+			if !ok {
+				return fmt.Errorf("unsupported shell: %s", args[0])
+			}
+
+			tmpl, err := template.New(args[0]).Parse(script)
+			if err != nil {
+				return fmt.Errorf("failed to parse %s script: %w", args[0], err)
+			}
+
+			return tmpl.Execute(cmd.OutOrStdout(), map[string]string{
+				"Command": widgetCommand(flags.insert),
+			})
+		},
+	}
+
+	cmd.Flags().BoolVar(&flags.insert, "insert", false, "insert the text of copy actions instead of copying it to the clipboard")
+	return cmd
+}
+
+// widgetCommand returns the command run by the widget. Its output is
+// captured, so the list is rendered on the terminal.
+func widgetCommand(insert bool) string {
+	if insert {
+		return "sunbeam --tty --insert"
+	}
+
+	return "sunbeam --tty"
+}
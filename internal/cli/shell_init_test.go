@@ -0,0 +1,68 @@
+package cli
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+func renderShellInit(t *testing.T, args ...string) string {
+	t.Helper()
+
+	var output bytes.Buffer
+	cmd := NewCmdShellInit()
+	cmd.SetArgs(args)
+	cmd.SetOut(&output)
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("failed to render %v: %v", args, err)
+	}
+
+	return output.String()
+}
+
+func TestShellInit(t *testing.T) {
+	for _, shell := range []string{"bash", "zsh", "fish"} {
+		t.Run(shell, func(t *testing.T) {
+			script := renderShellInit(t, shell)
+
+			golden := filepath.Join("testdata", "shell-init."+shell+".golden")
+			if *update {
+				if err := os.WriteFile(golden, []byte(script), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			expected, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if script != string(expected) {
+				t.Errorf("%s script does not match %s, run go test with -update to update it:\n%s", shell, golden, script)
+			}
+		})
+	}
+}
+
+func TestShellInitInsert(t *testing.T) {
+	for _, shell := range []string{"bash", "zsh", "fish"} {
+		if script := renderShellInit(t, shell, "--insert"); !strings.Contains(script, "sunbeam --tty --insert") {
+			t.Errorf("expected the %s widget to run in insert mode:\n%s", shell, script)
+		}
+	}
+}
+
+func TestShellInitUnsupportedShell(t *testing.T) {
+	cmd := NewCmdShellInit()
+	cmd.SetArgs([]string{"powershell"})
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+	if err := cmd.Execute(); err == nil {
+		t.Error("expected an error for an unsupported shell")
+	}
+}
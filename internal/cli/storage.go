@@ -0,0 +1,155 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/mattn/go-isatty"
+	"github.com/pomdtr/sunbeam/internal/config"
+	"github.com/pomdtr/sunbeam/internal/extensions"
+	"github.com/spf13/cobra"
+)
+
+func NewCmdStorage(cfg config.Config) *cobra.Command {
+	var flags struct {
+		extension string
+	}
+
+	// storage resolves the store of the extension. When run from an
+	// extension, the flag can be omitted.
+	storage := func() (extensions.Storage, error) {
+		if flags.extension == "" {
+			if dataDir, ok := os.LookupEnv("SUNBEAM_DATA_DIR"); ok {
+				return extensions.NewStorage(dataDir), nil
+			}
+
+			return extensions.Storage{}, fmt.Errorf("either provide an extension or run the command from an extension")
+		}
+
+		extensionConfig, ok := cfg.Extensions[flags.extension]
+		if !ok {
+			return extensions.Storage{}, fmt.Errorf("extension %s not found", flags.extension)
+		}
+
+		extension, err := extensions.LoadExtension(extensionConfig.Origin)
+		if err != nil {
+			return extensions.Storage{}, fmt.Errorf("failed to load extension %s: %w", flags.extension, err)
+		}
+
+		return extension.Storage(), nil
+	}
+
+	cmd := &cobra.Command{
+		Use:     "storage",
+		Short:   "Manage the persistent storage of the extensions",
+		GroupID: CommandGroupCore,
+	}
+
+	cmd.PersistentFlags().StringVar(&flags.extension, "extension", "", "alias of the extension")
+	_ = cmd.RegisterFlagCompletionFunc("extension", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return cfg.Aliases(), cobra.ShellCompDirectiveNoFileComp
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "get <key>",
+		Short: "Print the value of a key",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			storage, err := storage()
+			if err != nil {
+				return err
+			}
+
+			value, ok, err := storage.Get(args[0])
+			if err != nil {
+				return err
+			}
+
+			if !ok {
+				return fmt.Errorf("key %s not found", args[0])
+			}
+
+			fmt.Fprintln(cmd.OutOrStdout(), value)
+			return nil
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "set <key> [value]",
+		Short: "Set the value of a key, reading it from stdin if not provided",
+		Args:  cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			storage, err := storage()
+			if err != nil {
+				return err
+			}
+
+			var value string
+			if len(args) > 1 {
+				value = args[1]
+			} else if !isatty.IsTerminal(os.Stdin.Fd()) {
+				bts, err := io.ReadAll(os.Stdin)
+				if err != nil {
+					return fmt.Errorf("failed to read stdin: %w", err)
+				}
+				value = strings.TrimSuffix(string(bts), "\n")
+			} else {
+				return fmt.Errorf("missing value")
+			}
+
+			return storage.Set(args[0], value)
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:     "list",
+		Short:   "List the keys of the storage",
+		Aliases: []string{"ls"},
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			storage, err := storage()
+			if err != nil {
+				return err
+			}
+
+			keys, err := storage.Keys()
+			if err != nil {
+				return err
+			}
+
+			for _, key := range keys {
+				fmt.Fprintln(cmd.OutOrStdout(), key)
+			}
+
+			return nil
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:     "delete <key>",
+		Short:   "Delete a key",
+		Aliases: []string{"rm"},
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			storage, err := storage()
+			if err != nil {
+				return err
+			}
+
+			ok, err := storage.Delete(args[0])
+			if err != nil {
+				return err
+			}
+
+			if !ok {
+				return fmt.Errorf("key %s not found", args[0])
+			}
+
+			return nil
+		},
+	})
+
+	return cmd
+}
@@ -5,17 +5,26 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/pomdtr/sunbeam/internal/schemas"
 	"github.com/pomdtr/sunbeam/internal/utils"
 )
 
+// Path is the primary config file, where new entries are saved: the closest
+// sunbeam.json found walking up from the current directory, or the global one.
 var Path string
 
+// Layers lists the config files to merge, lowest precedence first: the global
+// config, then every project config up the tree, each followed by its
+// uncommitted sunbeam.local.json override.
+var Layers []string
+
 func init() {
 	if env, ok := os.LookupEnv("SUNBEAM_CONFIG"); ok {
 		Path = env
+		Layers = []string{env}
 		return
 	}
 
@@ -24,21 +33,41 @@ func init() {
 		panic(err)
 	}
 
+	var dirs []string
 	for currentDir != "/" {
-		if _, err := os.Stat(filepath.Join(currentDir, "sunbeam.json")); err == nil {
-			Path = filepath.Join(currentDir, "sunbeam.json")
-			return
-		}
+		dirs = append([]string{currentDir}, dirs...)
 		currentDir = filepath.Dir(currentDir)
 	}
 
 	Path = filepath.Join(utils.ConfigDir(), "sunbeam.json")
+	Layers = []string{Path, filepath.Join(utils.ConfigDir(), "sunbeam.local.json")}
+	for _, dir := range dirs {
+		if dir == utils.ConfigDir() {
+			continue
+		}
+
+		if _, err := os.Stat(filepath.Join(dir, "sunbeam.json")); err == nil {
+			Path = filepath.Join(dir, "sunbeam.json")
+			Layers = append(Layers, Path)
+		}
+
+		if _, err := os.Stat(filepath.Join(dir, "sunbeam.local.json")); err == nil {
+			Layers = append(Layers, filepath.Join(dir, "sunbeam.local.json"))
+		}
+	}
 }
 
 type Config struct {
 	Oneliners  []Oneliner                 `json:"oneliners,omitempty"`
 	Extensions map[string]ExtensionConfig `json:"extensions,omitempty"`
-	path       string                     `json:"-"`
+	// Timeout is the default timeout of the extension commands, in seconds
+	Timeout int    `json:"timeout,omitempty"`
+	path    string `json:"-"`
+
+	layers  []layer
+	sources map[string]string
+	// loaded is only set when the config was read from a file
+	loaded bool
 }
 
 func (cfg Config) Resolve(path string) string {
@@ -66,11 +95,13 @@ type RootItem struct {
 }
 
 type Oneliner struct {
-	Title       string `json:"title"`
-	Command     string `json:"command"`
-	Interactive bool   `json:"interactive,omitempty"`
-	Cwd         string `json:"cwd,omitempty"`
-	Exit        bool   `json:"exit,omitempty"`
+	Title       string            `json:"title"`
+	Command     string            `json:"command"`
+	Interactive bool              `json:"interactive,omitempty"`
+	Cwd         string            `json:"cwd,omitempty"`
+	Exit        bool              `json:"exit,omitempty"`
+	Env         map[string]string `json:"env,omitempty"`
+	Stdin       string            `json:"stdin,omitempty"`
 }
 
 func (cfg Config) Aliases() []string {
@@ -82,31 +113,80 @@ func (cfg Config) Aliases() []string {
 	return aliases
 }
 
-func Load(configPath string) (Config, error) {
-	configBytes, err := os.ReadFile(configPath)
-	if err != nil {
-		return Config{}, fmt.Errorf("failed to load config: %w", err)
+// Load merges the config layers, lowest precedence first. Missing layers are
+// skipped, except the primary one, which receives the new entries on save.
+func Load(configPath string, layers ...string) (Config, error) {
+	if !slices.Contains(layers, configPath) {
+		layers = append(layers, configPath)
 	}
 
-	if err := schemas.ValidateConfig(configBytes); err != nil {
-		return Config{}, fmt.Errorf("invalid config: %w", err)
-	}
+	var loaded []layer
+	for _, layerPath := range layers {
+		configBytes, err := os.ReadFile(layerPath)
+		if os.IsNotExist(err) && layerPath != configPath {
+			continue
+		} else if err != nil {
+			return Config{}, fmt.Errorf("failed to load config: %w", err)
+		}
+
+		if err := schemas.ValidateConfig(configBytes); err != nil {
+			return Config{}, fmt.Errorf("invalid config %s: %w", layerPath, err)
+		}
+
+		var config Config
+		if err := json.Unmarshal(configBytes, &config); err != nil {
+			return Config{}, fmt.Errorf("failed to unmarshal config %s: %w", layerPath, err)
+		}
+		config.path = layerPath
 
-	// set default values for configs
-	config := Config{
-		Extensions: make(map[string]ExtensionConfig),
+		loaded = append(loaded, layer{path: layerPath, config: config})
 	}
 
-	if err := json.Unmarshal(configBytes, &config); err != nil {
-		return Config{}, fmt.Errorf("failed to unmarshal config: %w", err)
+	config, sources, err := merge(loaded)
+	if err != nil {
+		return Config{}, err
 	}
 	config.path = configPath
+	config.layers = loaded
+	config.sources = sources
+	config.loaded = len(loaded) > 0
 
 	return config, nil
 }
 
+// Loaded reports whether the config was read from a file. Configs built in
+// memory have no file to save their changes to.
+func (c Config) Loaded() bool {
+	return c.loaded
+}
+
+// Save writes the changes made since the config was loaded. Each change is
+// written to the layer the entry comes from, new entries go to the primary one.
 func (c Config) Save() error {
-	f, err := os.Create(c.path)
+	if len(c.layers) == 0 {
+		return writeConfig(c.path, c)
+	}
+
+	dirty, err := c.apply()
+	if err != nil {
+		return err
+	}
+
+	for _, l := range c.layers {
+		if !dirty[l.path] {
+			continue
+		}
+
+		if err := writeConfig(l.path, l.config); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func writeConfig(configPath string, config Config) error {
+	f, err := os.Create(configPath)
 	if err != nil {
 		return fmt.Errorf("failed to open config: %w", err)
 	}
@@ -115,7 +195,7 @@ func (c Config) Save() error {
 	encoder := json.NewEncoder(f)
 	encoder.SetIndent("", "  ")
 	encoder.SetEscapeHTML(false)
-	if err := encoder.Encode(c); err != nil {
+	if err := encoder.Encode(config); err != nil {
 		return fmt.Errorf("failed to encode config: %w", err)
 	}
 
@@ -0,0 +1,340 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Layers are merged entry by entry: oneliners by title, extensions by alias,
+// then preferences by name and root items by title. The last layer defining
+// an entry wins.
+
+type layer struct {
+	path   string
+	config Config
+}
+
+// Source describes the file an entry of the config comes from.
+type Source struct {
+	Entry string
+	Path  string
+}
+
+// Sources returns the file each entry comes from, sorted by entry.
+func (c Config) Sources() []Source {
+	sources := make([]Source, 0, len(c.sources))
+	for entry, path := range c.sources {
+		sources = append(sources, Source{Entry: entry, Path: path})
+	}
+
+	sort.Slice(sources, func(i, j int) bool {
+		return sources[i].Entry < sources[j].Entry
+	})
+
+	return sources
+}
+
+func onelinerKey(title string) string {
+	return fmt.Sprintf("oneliners.%s", title)
+}
+
+func extensionKey(alias string) string {
+	return fmt.Sprintf("extensions.%s", alias)
+}
+
+func preferenceKey(alias string, name string) string {
+	return fmt.Sprintf("extensions.%s.preferences.%s", alias, name)
+}
+
+func rootKey(alias string, title string) string {
+	return fmt.Sprintf("extensions.%s.root.%s", alias, title)
+}
+
+func merge(layers []layer) (Config, map[string]string, error) {
+	config := Config{
+		Extensions: make(map[string]ExtensionConfig),
+	}
+	sources := make(map[string]string)
+
+	for _, l := range layers {
+		if l.config.Timeout != 0 {
+			config.Timeout = l.config.Timeout
+			sources["timeout"] = l.path
+		}
+
+		for _, oneliner := range l.config.Oneliners {
+			config.Oneliners = mergeOneliner(config.Oneliners, oneliner)
+			sources[onelinerKey(oneliner.Title)] = l.path
+		}
+
+		for alias, extension := range l.config.Extensions {
+			merged := copyExtension(config.Extensions[alias])
+			if extension.Origin != "" {
+				merged.Origin = resolveOrigin(extension.Origin, l.path)
+				sources[extensionKey(alias)] = l.path
+			}
+
+			for name, value := range extension.Preferences {
+				if merged.Preferences == nil {
+					merged.Preferences = make(map[string]any)
+				}
+
+				merged.Preferences[name] = value
+				sources[preferenceKey(alias, name)] = l.path
+			}
+
+			for _, item := range extension.Root {
+				merged.Root = mergeRootItem(merged.Root, item)
+				sources[rootKey(alias, item.Title)] = l.path
+			}
+
+			config.Extensions[alias] = merged
+		}
+	}
+
+	for alias, extension := range config.Extensions {
+		if extension.Origin == "" {
+			return Config{}, nil, fmt.Errorf("extension %s has no origin", alias)
+		}
+	}
+
+	return config, sources, nil
+}
+
+// resolveOrigin makes relative origins relative to the layer they are defined in.
+func resolveOrigin(origin string, layerPath string) string {
+	if strings.HasPrefix(origin, "http://") || strings.HasPrefix(origin, "https://") || strings.HasPrefix(origin, "~") || filepath.IsAbs(origin) {
+		return origin
+	}
+
+	return filepath.Join(filepath.Dir(layerPath), origin)
+}
+
+// RelativeOrigins returns the origins of the extensions as they are written
+// in their config file, for the ones relative to it, keyed by alias.
+func (c Config) RelativeOrigins() map[string]string {
+	origins := make(map[string]string)
+	for _, l := range c.layers {
+		for alias, extension := range l.config.Extensions {
+			if c.sources[extensionKey(alias)] != l.path || extension.Origin == "" {
+				continue
+			}
+
+			if resolveOrigin(extension.Origin, l.path) != extension.Origin {
+				origins[alias] = extension.Origin
+			}
+		}
+	}
+
+	return origins
+}
+
+func mergeOneliner(oneliners []Oneliner, oneliner Oneliner) []Oneliner {
+	for i := range oneliners {
+		if oneliners[i].Title == oneliner.Title {
+			oneliners[i] = oneliner
+			return oneliners
+		}
+	}
+
+	return append(oneliners, oneliner)
+}
+
+func mergeRootItem(items []RootItem, item RootItem) []RootItem {
+	for i := range items {
+		if items[i].Title == item.Title {
+			items[i] = item
+			return items
+		}
+	}
+
+	return append(items, item)
+}
+
+func copyExtension(extension ExtensionConfig) ExtensionConfig {
+	if extension.Preferences != nil {
+		preferences := make(map[string]any)
+		for name, value := range extension.Preferences {
+			preferences[name] = value
+		}
+		extension.Preferences = preferences
+	}
+
+	extension.Root = append([]RootItem(nil), extension.Root...)
+	return extension
+}
+
+// apply writes the differences between the config and the merge of its
+// layers to the layers. It returns the paths of the modified layers.
+func (c Config) apply() (map[string]bool, error) {
+	original, _, err := merge(c.layers)
+	if err != nil {
+		return nil, err
+	}
+
+	layers := make(map[string]*Config)
+	for i := range c.layers {
+		layers[c.layers[i].path] = &c.layers[i].config
+	}
+
+	primary, ok := layers[c.path]
+	if !ok {
+		return nil, fmt.Errorf("primary config %s is not loaded", c.path)
+	}
+
+	dirty := make(map[string]bool)
+	// source returns the layer an entry comes from, or the fallback one
+	source := func(key string, fallback string) (string, *Config) {
+		if path, ok := c.sources[key]; ok {
+			return path, layers[path]
+		}
+
+		return fallback, layers[fallback]
+	}
+
+	if c.Timeout != original.Timeout {
+		path, layer := source("timeout", c.path)
+		layer.Timeout = c.Timeout
+		dirty[path] = true
+	}
+
+	titles := make(map[string]bool)
+	for _, oneliner := range c.Oneliners {
+		titles[oneliner.Title] = true
+	}
+
+	for _, oneliner := range original.Oneliners {
+		if titles[oneliner.Title] {
+			continue
+		}
+
+		for path, layer := range layers {
+			for i := range layer.Oneliners {
+				if layer.Oneliners[i].Title == oneliner.Title {
+					layer.Oneliners = append(layer.Oneliners[:i], layer.Oneliners[i+1:]...)
+					dirty[path] = true
+					break
+				}
+			}
+		}
+	}
+
+	for _, oneliner := range c.Oneliners {
+		var previous *Oneliner
+		for i := range original.Oneliners {
+			if original.Oneliners[i].Title == oneliner.Title {
+				previous = &original.Oneliners[i]
+			}
+		}
+
+		if previous != nil && reflect.DeepEqual(*previous, oneliner) {
+			continue
+		}
+
+		path, layer := source(onelinerKey(oneliner.Title), c.path)
+		layer.Oneliners = mergeOneliner(layer.Oneliners, oneliner)
+		dirty[path] = true
+	}
+
+	for alias := range original.Extensions {
+		if _, ok := c.Extensions[alias]; ok {
+			continue
+		}
+
+		for path, layer := range layers {
+			if _, ok := layer.Extensions[alias]; ok {
+				delete(layer.Extensions, alias)
+				dirty[path] = true
+			}
+		}
+	}
+
+	for alias, extension := range c.Extensions {
+		previous, ok := original.Extensions[alias]
+		if !ok {
+			if primary.Extensions == nil {
+				primary.Extensions = make(map[string]ExtensionConfig)
+			}
+
+			primary.Extensions[alias] = extension
+			dirty[c.path] = true
+			continue
+		}
+
+		if reflect.DeepEqual(previous, extension) {
+			continue
+		}
+
+		originPath, _ := source(extensionKey(alias), c.path)
+		if extension.Origin != previous.Origin {
+			updateExtension(layers[originPath], alias, func(e *ExtensionConfig) {
+				e.Origin = extension.Origin
+			})
+			dirty[originPath] = true
+		}
+
+		for name := range previous.Preferences {
+			if _, ok := extension.Preferences[name]; ok {
+				continue
+			}
+
+			for path, layer := range layers {
+				if _, ok := layer.Extensions[alias].Preferences[name]; ok {
+					updateExtension(layer, alias, func(e *ExtensionConfig) {
+						delete(e.Preferences, name)
+					})
+					dirty[path] = true
+				}
+			}
+		}
+
+		for name, value := range extension.Preferences {
+			if reflect.DeepEqual(previous.Preferences[name], value) {
+				continue
+			}
+
+			path, layer := source(preferenceKey(alias, name), originPath)
+			updateExtension(layer, alias, func(e *ExtensionConfig) {
+				if e.Preferences == nil {
+					e.Preferences = make(map[string]any)
+				}
+				e.Preferences[name] = value
+			})
+			dirty[path] = true
+		}
+
+		// root items are rewritten as a whole, in the layer of the extension
+		if !reflect.DeepEqual(previous.Root, extension.Root) {
+			for path, layer := range layers {
+				if _, ok := layer.Extensions[alias]; ok && path != originPath {
+					updateExtension(layer, alias, func(e *ExtensionConfig) {
+						if e.Root != nil {
+							e.Root = nil
+							dirty[path] = true
+						}
+					})
+				}
+			}
+
+			updateExtension(layers[originPath], alias, func(e *ExtensionConfig) {
+				e.Root = extension.Root
+			})
+			dirty[originPath] = true
+		}
+	}
+
+	return dirty, nil
+}
+
+func updateExtension(layer *Config, alias string, update func(*ExtensionConfig)) {
+	if layer.Extensions == nil {
+		layer.Extensions = make(map[string]ExtensionConfig)
+	}
+
+	extension := copyExtension(layer.Extensions[alias])
+	update(&extension)
+	layer.Extensions[alias] = extension
+}
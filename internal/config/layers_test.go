@@ -0,0 +1,222 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestMerge(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		layers   []layer
+		expected map[string]ExtensionConfig
+		sources  map[string]string
+		err      bool
+	}{
+		{
+			name: "project layer overrides a preference",
+			layers: []layer{
+				{path: "/home/user/.config/sunbeam/sunbeam.json", config: Config{Extensions: map[string]ExtensionConfig{
+					"github": {Origin: "/extensions/github.sh", Preferences: map[string]any{"token": "global", "user": "pomdtr"}},
+				}}},
+				{path: "/home/user/project/sunbeam.json", config: Config{Extensions: map[string]ExtensionConfig{
+					"github": {Preferences: map[string]any{"token": "project"}},
+				}}},
+			},
+			expected: map[string]ExtensionConfig{
+				"github": {Origin: "/extensions/github.sh", Preferences: map[string]any{"token": "project", "user": "pomdtr"}},
+			},
+			sources: map[string]string{
+				"extensions.github":                   "/home/user/.config/sunbeam/sunbeam.json",
+				"extensions.github.preferences.token": "/home/user/project/sunbeam.json",
+				"extensions.github.preferences.user":  "/home/user/.config/sunbeam/sunbeam.json",
+			},
+		},
+		{
+			name: "relative origins are resolved against their layer",
+			layers: []layer{
+				{path: "/home/user/.config/sunbeam/sunbeam.json", config: Config{Extensions: map[string]ExtensionConfig{
+					"home":   {Origin: "~/extensions/home.sh"},
+					"remote": {Origin: "https://example.com/extension.sh"},
+				}}},
+				{path: "/home/user/project/sunbeam.json", config: Config{Extensions: map[string]ExtensionConfig{
+					"local": {Origin: "./extensions/local.sh"},
+				}}},
+			},
+			expected: map[string]ExtensionConfig{
+				"home":   {Origin: "~/extensions/home.sh"},
+				"remote": {Origin: "https://example.com/extension.sh"},
+				"local":  {Origin: "/home/user/project/extensions/local.sh"},
+			},
+			sources: map[string]string{
+				"extensions.home":   "/home/user/.config/sunbeam/sunbeam.json",
+				"extensions.remote": "/home/user/.config/sunbeam/sunbeam.json",
+				"extensions.local":  "/home/user/project/sunbeam.json",
+			},
+		},
+		{
+			name: "extension without origin in any layer",
+			layers: []layer{
+				{path: "/home/user/project/sunbeam.json", config: Config{Extensions: map[string]ExtensionConfig{
+					"github": {Preferences: map[string]any{"token": "project"}},
+				}}},
+			},
+			err: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			config, sources, err := merge(tc.layers)
+			if tc.err {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(config.Extensions, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, config.Extensions)
+			}
+
+			if !reflect.DeepEqual(sources, tc.sources) {
+				t.Errorf("expected sources %v, got %v", tc.sources, sources)
+			}
+		})
+	}
+}
+
+func TestSave(t *testing.T) {
+	global := Config{Extensions: map[string]ExtensionConfig{
+		"github": {Origin: "/extensions/github.sh", Preferences: map[string]any{"token": "global", "user": "pomdtr"}},
+	}}
+	project := Config{Extensions: map[string]ExtensionConfig{
+		"github": {Preferences: map[string]any{"token": "project"}},
+	}}
+
+	for _, tc := range []struct {
+		name    string
+		update  func(*Config)
+		global  Config
+		project Config
+	}{
+		{
+			name: "preference of the global layer",
+			update: func(c *Config) {
+				c.Extensions["github"].Preferences["user"] = "octocat"
+			},
+			global: Config{Extensions: map[string]ExtensionConfig{
+				"github": {Origin: "/extensions/github.sh", Preferences: map[string]any{"token": "global", "user": "octocat"}},
+			}},
+			project: project,
+		},
+		{
+			name: "preference overridden by the project layer",
+			update: func(c *Config) {
+				c.Extensions["github"].Preferences["token"] = "updated"
+			},
+			global: global,
+			project: Config{Extensions: map[string]ExtensionConfig{
+				"github": {Preferences: map[string]any{"token": "updated"}},
+			}},
+		},
+		{
+			name: "new extension",
+			update: func(c *Config) {
+				c.Extensions["raindrop"] = ExtensionConfig{Origin: "/extensions/raindrop.sh"}
+			},
+			global: global,
+			project: Config{Extensions: map[string]ExtensionConfig{
+				"github":   {Preferences: map[string]any{"token": "project"}},
+				"raindrop": {Origin: "/extensions/raindrop.sh"},
+			}},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			globalPath := filepath.Join(dir, "global", "sunbeam.json")
+			projectPath := filepath.Join(dir, "project", "sunbeam.json")
+			writeLayer(t, globalPath, global)
+			writeLayer(t, projectPath, project)
+
+			config, err := Load(projectPath, globalPath, projectPath)
+			if err != nil {
+				t.Fatalf("failed to load config: %v", err)
+			}
+
+			if !config.Loaded() {
+				t.Fatal("expected the config to be loaded from its files")
+			}
+
+			tc.update(&config)
+			if err := config.Save(); err != nil {
+				t.Fatalf("failed to save config: %v", err)
+			}
+
+			if saved := readLayer(t, globalPath); !reflect.DeepEqual(saved, tc.global) {
+				t.Errorf("expected global layer %v, got %v", tc.global, saved)
+			}
+
+			if saved := readLayer(t, projectPath); !reflect.DeepEqual(saved, tc.project) {
+				t.Errorf("expected project layer %v, got %v", tc.project, saved)
+			}
+		})
+	}
+}
+
+func writeLayer(t *testing.T, path string, config Config) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := writeConfig(path, config); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readLayer(t *testing.T, path string) Config {
+	t.Helper()
+
+	bts, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var config Config
+	if err := json.Unmarshal(bts, &config); err != nil {
+		t.Fatal(err)
+	}
+
+	return config
+}
+
+func TestRelativeOrigins(t *testing.T) {
+	dir := t.TempDir()
+	globalPath := filepath.Join(dir, "global", "sunbeam.json")
+	projectPath := filepath.Join(dir, "project", "sunbeam.json")
+	writeLayer(t, globalPath, Config{Extensions: map[string]ExtensionConfig{
+		"github": {Origin: "./github.sh"},
+		"remote": {Origin: "https://example.com/extension.sh"},
+	}})
+	writeLayer(t, projectPath, Config{Extensions: map[string]ExtensionConfig{
+		"github": {Preferences: map[string]any{"token": "project"}},
+		"local":  {Origin: "extensions/local.sh"},
+	}})
+
+	config, err := Load(projectPath, globalPath, projectPath)
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+
+	expected := map[string]string{"github": "./github.sh", "local": "extensions/local.sh"}
+	if origins := config.RelativeOrigins(); !reflect.DeepEqual(origins, expected) {
+		t.Errorf("expected %v, got %v", expected, origins)
+	}
+}
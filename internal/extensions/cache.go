@@ -0,0 +1,103 @@
+package extensions
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pomdtr/sunbeam/pkg/sunbeam"
+)
+
+// The output of the commands with a cache setting is stored in the cache
+// directory of the extension, keyed by command, params, query and resolved
+// preferences: the outputs of another account are never shown. Only the
+// hash of the key is stored, the preferences may contain secrets.
+
+type cacheEntry struct {
+	CreatedAt time.Time       `json:"createdAt"`
+	Output    json.RawMessage `json:"output"`
+}
+
+// CacheDir returns the directory of the cached outputs, or an empty string
+// if the extension has no cache directory.
+func (e Extension) CacheDir() string {
+	if e.Dir == "" {
+		return ""
+	}
+
+	return filepath.Join(e.Dir, "cache")
+}
+
+func (e Extension) cachePath(input sunbeam.Payload) (string, bool) {
+	command, ok := e.Command(input.Command)
+	if !ok || command.Cache == nil || e.CacheDir() == "" {
+		return "", false
+	}
+
+	preferences, err := e.ResolvePreferences(input.Preferences)
+	if err != nil {
+		return "", false
+	}
+
+	key, err := json.Marshal(struct {
+		Command     string         `json:"command"`
+		Params      map[string]any `json:"params,omitempty"`
+		Query       string         `json:"query,omitempty"`
+		Preferences map[string]any `json:"preferences,omitempty"`
+	}{input.Command, input.Params, input.Query, preferences})
+	if err != nil {
+		return "", false
+	}
+
+	hash := sha1.Sum(key)
+	return filepath.Join(e.CacheDir(), hex.EncodeToString(hash[:])+".json"), true
+}
+
+// ReadCache returns the cached output of the command, if it is not older
+// than the ttl of the command.
+func (e Extension) ReadCache(input sunbeam.Payload) ([]byte, bool) {
+	cachePath, ok := e.cachePath(input)
+	if !ok {
+		return nil, false
+	}
+
+	bts, err := os.ReadFile(cachePath)
+	if err != nil {
+		return nil, false
+	}
+
+	var entry cacheEntry
+	if err := json.Unmarshal(bts, &entry); err != nil {
+		return nil, false
+	}
+
+	command, _ := e.Command(input.Command)
+	if command.Cache.TTL > 0 && time.Since(entry.CreatedAt) > time.Duration(command.Cache.TTL)*time.Second {
+		return nil, false
+	}
+
+	return entry.Output, true
+}
+
+// WriteCache stores the output of the command, if the command has a cache setting.
+func (e Extension) WriteCache(input sunbeam.Payload, output []byte) error {
+	cachePath, ok := e.cachePath(input)
+	if !ok {
+		return nil
+	}
+
+	bts, err := json.Marshal(cacheEntry{CreatedAt: time.Now(), Output: output})
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(cachePath), 0755); err != nil {
+		return fmt.Errorf("failed to create cache dir: %w", err)
+	}
+
+	return os.WriteFile(cachePath, bts, 0600)
+}
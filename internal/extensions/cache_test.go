@@ -0,0 +1,43 @@
+package extensions
+
+import (
+	"testing"
+
+	"github.com/pomdtr/sunbeam/pkg/sunbeam"
+)
+
+func TestCacheIsKeyedByPreferences(t *testing.T) {
+	t.Setenv("SUNBEAM_TEST_TOKEN", "token-a")
+	extension := Extension{
+		Dir: t.TempDir(),
+		Manifest: sunbeam.Manifest{
+			Preferences: []sunbeam.Input{
+				{Name: "token", Type: sunbeam.InputString},
+			},
+			Commands: []sunbeam.CommandSpec{
+				{Name: "list", Mode: sunbeam.CommandModeFilter, Cache: &sunbeam.CacheSpec{}},
+			},
+		},
+	}
+
+	input := sunbeam.Payload{
+		Command:     "list",
+		Preferences: map[string]any{"token": map[string]any{"$env": "SUNBEAM_TEST_TOKEN"}},
+	}
+	if err := extension.WriteCache(input, []byte(`{"items": []}`)); err != nil {
+		t.Fatalf("failed to write cache: %v", err)
+	}
+
+	if _, ok := extension.ReadCache(input); !ok {
+		t.Fatal("expected the cached output")
+	}
+
+	if _, ok := extension.ReadCache(sunbeam.Payload{Command: "list", Preferences: map[string]any{"token": "token-a"}}); !ok {
+		t.Error("expected the resolved preferences to share the cached output")
+	}
+
+	t.Setenv("SUNBEAM_TEST_TOKEN", "token-b")
+	if _, ok := extension.ReadCache(input); ok {
+		t.Error("expected the output of another token not to be cached")
+	}
+}
@@ -1,22 +1,24 @@
 package extensions
 
 import (
+	"bytes"
 	"context"
 	"crypto/sha1"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/acarl005/stripansi"
 	"github.com/pomdtr/sunbeam/internal/config"
-	"github.com/pomdtr/sunbeam/internal/schemas"
 	"github.com/pomdtr/sunbeam/internal/utils"
 	"github.com/pomdtr/sunbeam/pkg/sunbeam"
 )
@@ -33,7 +35,12 @@ func (e ExtensionMap) List() []Extension {
 
 type Extension struct {
 	Manifest   sunbeam.Manifest
-	Entrypoint string `json:"entrypoint"`
+	Type       ExtensionType `json:"type"`
+	Entrypoint string        `json:"entrypoint"`
+	// Dir is the cache directory of the extension, where its logs are stored
+	Dir string `json:"dir,omitempty"`
+	// DataDir is where the extension keeps its state, see Storage
+	DataDir string `json:"dataDir,omitempty"`
 }
 
 type Preferences map[string]any
@@ -73,43 +80,208 @@ func (e Extension) RootCommands() []sunbeam.CommandSpec {
 	return rootCommands
 }
 
+// Options returns the options of a select input. If the input has an options
+// command, each item of the list returned by the command is an option.
+func (e Extension) Options(input sunbeam.Input, preferences map[string]any) ([]sunbeam.InputOption, error) {
+	options := make([]sunbeam.InputOption, 0, len(input.Options))
+	options = append(options, input.Options...)
+	if input.OptionsCommand == "" {
+		return options, nil
+	}
+
+	output, err := e.Output(sunbeam.Payload{
+		Command:     input.OptionsCommand,
+		Preferences: preferences,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// the command can either print a list, or one item per line
+	decoder := json.NewDecoder(bytes.NewReader(output))
+	for {
+		var value struct {
+			sunbeam.ListItem
+			Items []sunbeam.ListItem `json:"items"`
+		}
+
+		if err := decoder.Decode(&value); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("invalid options: %w", err)
+		}
+
+		items := value.Items
+		if value.Title != "" {
+			items = append(items, value.ListItem)
+		}
+
+		for _, item := range items {
+			option := sunbeam.InputOption{Title: item.Title, Value: item.Id}
+			if option.Value == "" {
+				option.Value = item.Title
+			}
+
+			options = append(options, option)
+		}
+	}
+
+	return options, nil
+}
+
 func (e Extension) Run(input sunbeam.Payload) error {
 	_, err := e.Output(input)
 	return err
 }
 
 func (ext Extension) Output(input sunbeam.Payload) ([]byte, error) {
-	cmd, err := ext.Cmd(input)
+	return ext.OutputContext(context.Background(), input)
+}
+
+// OneShot reports whether each command is run as a separate process.
+func (ext Extension) OneShot() bool {
+	return ext.Type != ExtensionTypeHttp && !ext.Manifest.Persistent
+}
+
+// OutputContext runs the command, and returns its output. The command is
+// stopped if it does not complete before its timeout.
+func (ext Extension) OutputContext(ctx context.Context, input sunbeam.Payload) ([]byte, error) {
+	timeout := ext.Timeout(input.Command)
+	ctx, cancel := WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	output, stderr, err := ext.output(ctx, input)
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		err = TimeoutError{Timeout: timeout}
+	}
+	ext.Log(input, start, stderr, err)
+
+	return output, err
+}
+
+// output returns the output of the command, and its stderr if it was run as
+// a separate process.
+func (ext Extension) output(ctx context.Context, input sunbeam.Payload) ([]byte, string, error) {
+	if ext.Type == ExtensionTypeHttp {
+		payload, err := ext.payload(input)
+		if err != nil {
+			return nil, "", err
+		}
+
+		output, err := ext.post(ctx, payload)
+		return output, "", err
+	}
+
+	if ext.Manifest.Persistent {
+		payload, err := ext.payload(input)
+		if err != nil {
+			return nil, "", err
+		}
+
+		process, err := ext.process()
+		if err != nil {
+			return nil, "", err
+		}
+
+		output, err := process.Call(ctx, payload)
+		return output, "", err
+	}
+
+	cmd, err := ext.CmdContext(ctx, input)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
 	var exitErr *exec.ExitError
 	if output, err := cmd.Output(); err == nil {
-		return output, nil
+		return output, stderr.String(), nil
 	} else if errors.As(err, &exitErr) {
-		return nil, fmt.Errorf("command failed: %s", stripansi.Strip(string(exitErr.Stderr)))
+		return nil, stderr.String(), commandError{code: exitErr.ExitCode(), stderr: stripansi.Strip(stderr.String())}
 	} else {
-		return nil, err
+		return nil, stderr.String(), err
 	}
 }
 
+// Cmd returns the command to run the extension attached to the terminal.
 func (e Extension) Cmd(input sunbeam.Payload) (*exec.Cmd, error) {
-	return e.CmdContext(context.Background(), input)
+	return e.InteractiveCmdContext(context.Background(), input)
+}
+
+// InteractiveCmdContext is like Cmd, but the process is killed when ctx is
+// done. It stays in the foreground process group, so it can read from the
+// terminal.
+func (e Extension) InteractiveCmdContext(ctx context.Context, input sunbeam.Payload) (*exec.Cmd, error) {
+	return e.command(ctx, input)
+}
+
+// CmdContext returns the command to run the extension in the background. The
+// process is started in its own process group, and the whole group is stopped
+// when ctx is done.
+func (e Extension) CmdContext(ctx context.Context, input sunbeam.Payload) (*GroupCmd, error) {
+	cmd, err := e.command(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	groupCmd := &GroupCmd{Cmd: cmd}
+	setProcessGroup(groupCmd)
+	return groupCmd, nil
+}
+
+func (e Extension) command(ctx context.Context, input sunbeam.Payload) (*exec.Cmd, error) {
+	if e.Type == ExtensionTypeHttp {
+		return nil, fmt.Errorf("http extensions can not be run as a process")
+	}
+
+	input, err := e.payload(input)
+	if err != nil {
+		return nil, err
+	}
+
+	inputBytes, err := json.Marshal(input)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := exec.CommandContext(ctx, e.Entrypoint, string(inputBytes))
+	cmd.Dir = filepath.Dir(e.Entrypoint)
+	cmd.Env = e.environ()
+	return cmd, nil
 }
 
-func (e Extension) CmdContext(ctx context.Context, input sunbeam.Payload) (*exec.Cmd, error) {
-	if input.Preferences == nil {
-		input.Preferences = make(map[string]any)
+func (e Extension) environ() []string {
+	env := os.Environ()
+	env = append(env, "SUNBEAM=1")
+	if e.DataDir != "" {
+		env = append(env, fmt.Sprintf("SUNBEAM_DATA_DIR=%s", e.DataDir))
 	}
 
+	return env
+}
+
+// payload fills the defaults of the input, and checks that all the required
+// preferences and params are set.
+func (e Extension) payload(input sunbeam.Payload) (sunbeam.Payload, error) {
+	preferences, err := e.ResolvePreferences(input.Preferences)
+	if err != nil {
+		return sunbeam.Payload{}, err
+	}
+	input.Preferences = preferences
+
 	for _, spec := range e.Manifest.Preferences {
-		if _, ok := input.Preferences[spec.Name]; ok {
+		if value, ok := input.Preferences[spec.Name]; ok && value != nil {
+			if err := spec.Validate(value); err != nil {
+				return sunbeam.Payload{}, fmt.Errorf("invalid preference %s: %w", spec.Name, err)
+			}
 			continue
 		}
 
 		if !spec.Optional {
-			return nil, fmt.Errorf("missing required preference %s", spec.Name)
+			return sunbeam.Payload{}, fmt.Errorf("missing required preference %s", spec.Name)
 		}
 
 		input.Preferences[spec.Name] = spec.Default
@@ -117,7 +289,7 @@ func (e Extension) CmdContext(ctx context.Context, input sunbeam.Payload) (*exec
 
 	command, ok := e.Command(input.Command)
 	if !ok {
-		return nil, fmt.Errorf("command %s not found", input.Command)
+		return sunbeam.Payload{}, fmt.Errorf("command %s not found", input.Command)
 	}
 
 	if input.Params == nil {
@@ -125,12 +297,15 @@ func (e Extension) CmdContext(ctx context.Context, input sunbeam.Payload) (*exec
 	}
 
 	for _, spec := range command.Params {
-		if _, ok := input.Params[spec.Name]; ok {
+		if value, ok := input.Params[spec.Name]; ok && value != nil {
+			if err := spec.Validate(value); err != nil {
+				return sunbeam.Payload{}, fmt.Errorf("invalid parameter %s: %w", spec.Name, err)
+			}
 			continue
 		}
 
 		if !spec.Optional {
-			return nil, fmt.Errorf("missing required parameter %s", spec.Name)
+			return sunbeam.Payload{}, fmt.Errorf("missing required parameter %s", spec.Name)
 		}
 
 		input.Params[spec.Name] = spec.Default
@@ -138,20 +313,19 @@ func (e Extension) CmdContext(ctx context.Context, input sunbeam.Payload) (*exec
 
 	cwd, err := os.Getwd()
 	if err != nil {
-		return nil, err
+		return sunbeam.Payload{}, err
 	}
 	input.Cwd = cwd
 
-	inputBytes, err := json.Marshal(input)
-	if err != nil {
-		return nil, err
+	// http extensions do not share the filesystem of the user
+	if e.DataDir != "" && e.Type != ExtensionTypeHttp {
+		if err := os.MkdirAll(e.DataDir, 0700); err != nil {
+			return sunbeam.Payload{}, fmt.Errorf("failed to create data dir: %w", err)
+		}
+		input.DataDir = e.DataDir
 	}
 
-	cmd := exec.CommandContext(ctx, e.Entrypoint, string(inputBytes))
-	cmd.Dir = filepath.Dir(e.Entrypoint)
-	cmd.Env = os.Environ()
-	cmd.Env = append(cmd.Env, "SUNBEAM=1")
-	return cmd, nil
+	return input, nil
 }
 
 func Hash(origin string) (string, error) {
@@ -169,62 +343,188 @@ func Hash(origin string) (string, error) {
 	return hex.EncodeToString(h.Sum(nil)), nil
 }
 
+// MigrateDirs moves the cache and data directories of the extensions with a
+// relative origin. They used to be keyed by the origin resolved from the
+// current directory, they are now keyed by the origin resolved from the
+// config file defining it. Directories still used by another extension, or
+// already migrated, are left untouched.
+func MigrateDirs(cfg config.Config) error {
+	used := make(map[string]bool)
+	for _, extensionConfig := range cfg.Extensions {
+		hash, err := Hash(extensionConfig.Origin)
+		if err != nil {
+			return err
+		}
+		used[hash] = true
+	}
+
+	for alias, previousOrigin := range cfg.RelativeOrigins() {
+		previousHash, err := Hash(previousOrigin)
+		if err != nil {
+			return err
+		}
+
+		hash, err := Hash(cfg.Extensions[alias].Origin)
+		if err != nil {
+			return err
+		}
+
+		if previousHash == hash || used[previousHash] {
+			continue
+		}
+
+		for _, root := range []string{filepath.Join(utils.CacheDir(), "extensions"), filepath.Join(utils.DataDir(), "extensions")} {
+			previousDir, dir := filepath.Join(root, previousHash), filepath.Join(root, hash)
+			if _, err := os.Stat(previousDir); err != nil {
+				continue
+			}
+
+			if _, err := os.Stat(dir); err == nil {
+				continue
+			}
+
+			if err := os.Rename(previousDir, dir); err != nil {
+				return fmt.Errorf("failed to migrate the directory of %s: %w", alias, err)
+			}
+		}
+	}
+
+	return nil
+}
+
 func IsRemote(origin string) bool {
 	return strings.HasPrefix(origin, "http://") || strings.HasPrefix(origin, "https://")
 }
 
-func DownloadEntrypoint(origin string, target string) error {
-	resp, err := http.Get(origin)
+// FetchOrigin downloads the extension and caches it in the extension dir.
+// If the origin answers with a json manifest, the extension is an http extension.
+func FetchOrigin(ctx context.Context, origin string, extensionDir string) (Metadata, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, origin, nil)
 	if err != nil {
-		return fmt.Errorf("failed to download extension: %w", err)
+		return Metadata{}, err
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return Metadata{}, fmt.Errorf("failed to download extension: %w", httpError(err))
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != 200 {
-		return fmt.Errorf("failed to download extension: %s", resp.Status)
+		return Metadata{}, fmt.Errorf("failed to download extension: %s", resp.Status)
 	}
 
-	f, err := os.Create(target)
-	if err != nil {
-		return fmt.Errorf("failed to create entrypoint: %w", err)
+	if err := os.MkdirAll(extensionDir, 0755); err != nil {
+		return Metadata{}, fmt.Errorf("failed to create directory: %w", err)
 	}
 
-	if _, err := f.ReadFrom(resp.Body); err != nil {
-		return fmt.Errorf("failed to write entrypoint: %w", err)
-	}
+	var metadata Metadata
+	if isManifestResponse(resp) {
+		manifestBytes, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return Metadata{}, fmt.Errorf("failed to download manifest: %w", err)
+		}
 
-	if err := f.Close(); err != nil {
-		return fmt.Errorf("failed to close entrypoint: %w", err)
-	}
+		manifest, err := parseHttpManifest(manifestBytes)
+		if err != nil {
+			return Metadata{}, fmt.Errorf("invalid manifest: %w", err)
+		}
 
-	return nil
-}
+		if err := writeManifest(manifest, filepath.Join(extensionDir, "manifest.json")); err != nil {
+			return Metadata{}, err
+		}
 
-func LoadEntrypoint(origin string, extensionDir string) (string, error) {
-	if IsRemote(origin) {
-		originUrl, err := url.Parse(origin)
+		metadata = Metadata{
+			Type:       ExtensionTypeHttp,
+			Origin:     origin,
+			Entrypoint: origin,
+		}
+	} else {
+		entrypoint, err := remoteEntrypoint(origin, extensionDir)
 		if err != nil {
-			return "", fmt.Errorf("failed to parse origin: %w", err)
+			return Metadata{}, err
 		}
 
-		entrypoint := filepath.Join(extensionDir, filepath.Base(originUrl.Path))
-		if _, err := os.Stat(entrypoint); err == nil {
-			return entrypoint, nil
+		f, err := os.Create(entrypoint)
+		if err != nil {
+			return Metadata{}, fmt.Errorf("failed to create entrypoint: %w", err)
 		}
 
-		if err := os.MkdirAll(extensionDir, 0755); err != nil {
-			return "", fmt.Errorf("failed to create directory: %w", err)
+		if _, err := f.ReadFrom(resp.Body); err != nil {
+			return Metadata{}, fmt.Errorf("failed to write entrypoint: %w", err)
 		}
 
-		if err := DownloadEntrypoint(origin, entrypoint); err != nil {
-			return "", err
+		if err := f.Close(); err != nil {
+			return Metadata{}, fmt.Errorf("failed to close entrypoint: %w", err)
 		}
 
 		if err := os.Chmod(entrypoint, 0755); err != nil {
-			return "", fmt.Errorf("failed to chmod entrypoint: %w", err)
+			return Metadata{}, fmt.Errorf("failed to chmod entrypoint: %w", err)
+		}
+
+		metadata = Metadata{
+			Type:       ExtensionTypeLocal,
+			Origin:     origin,
+			Entrypoint: entrypoint,
+		}
+	}
+
+	metadataBytes, err := json.Marshal(metadata)
+	if err != nil {
+		return Metadata{}, err
+	}
+
+	if err := os.WriteFile(filepath.Join(extensionDir, "metadata.json"), metadataBytes, 0644); err != nil {
+		return Metadata{}, fmt.Errorf("failed to write metadata: %w", err)
+	}
+
+	return metadata, nil
+}
+
+func LoadMetadata(origin string, extensionDir string) (Metadata, error) {
+	if metadataBytes, err := os.ReadFile(filepath.Join(extensionDir, "metadata.json")); err == nil {
+		var metadata Metadata
+		if err := json.Unmarshal(metadataBytes, &metadata); err != nil {
+			return Metadata{}, fmt.Errorf("failed to decode metadata: %w", err)
+		}
+
+		return metadata, nil
+	}
+
+	// extensions downloaded by previous versions have no metadata
+	entrypoint, err := remoteEntrypoint(origin, extensionDir)
+	if err != nil {
+		return Metadata{}, err
+	}
+
+	if _, err := os.Stat(entrypoint); err == nil {
+		return Metadata{
+			Type:       ExtensionTypeLocal,
+			Origin:     origin,
+			Entrypoint: entrypoint,
+		}, nil
+	}
+
+	return FetchOrigin(context.Background(), origin, extensionDir)
+}
+
+func remoteEntrypoint(origin string, extensionDir string) (string, error) {
+	originUrl, err := url.Parse(origin)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse origin: %w", err)
+	}
+
+	return filepath.Join(extensionDir, filepath.Base(originUrl.Path)), nil
+}
+
+func LoadEntrypoint(origin string, extensionDir string) (string, error) {
+	if IsRemote(origin) {
+		metadata, err := LoadMetadata(origin, extensionDir)
+		if err != nil {
+			return "", err
 		}
 
-		return entrypoint, nil
+		return metadata.Entrypoint, nil
 	}
 
 	entrypoint := origin
@@ -243,6 +543,22 @@ func LoadExtension(origin string) (Extension, error) {
 		return Extension{}, err
 	}
 	extensionDir := filepath.Join(utils.CacheDir(), "extensions", hash)
+	dataDir, err := DataDir(origin)
+	if err != nil {
+		return Extension{}, err
+	}
+
+	if IsRemote(origin) {
+		metadata, err := LoadMetadata(origin, extensionDir)
+		if err != nil {
+			return Extension{}, err
+		}
+
+		if metadata.Type == ExtensionTypeHttp {
+			return loadHttpExtension(metadata, extensionDir, dataDir)
+		}
+	}
+
 	entrypoint, err := LoadEntrypoint(origin, extensionDir)
 	if err != nil {
 		return Extension{}, err
@@ -263,7 +579,10 @@ func LoadExtension(origin string) (Extension, error) {
 
 		return Extension{
 			Manifest:   manifest,
+			Type:       ExtensionTypeLocal,
 			Entrypoint: entrypoint,
+			Dir:        extensionDir,
+			DataDir:    dataDir,
 		}, nil
 	}
 
@@ -279,7 +598,43 @@ func LoadExtension(origin string) (Extension, error) {
 
 	return Extension{
 		Manifest:   manifest,
+		Type:       ExtensionTypeLocal,
 		Entrypoint: entrypoint,
+		Dir:        extensionDir,
+		DataDir:    dataDir,
+	}, nil
+}
+
+func loadHttpExtension(metadata Metadata, extensionDir string, dataDir string) (Extension, error) {
+	manifestPath := filepath.Join(extensionDir, "manifest.json")
+
+	var manifest sunbeam.Manifest
+	if manifestBytes, err := os.ReadFile(manifestPath); err == nil {
+		if err := json.Unmarshal(manifestBytes, &manifest); err != nil {
+			return Extension{}, fmt.Errorf("failed to decode manifest: %w", err)
+		}
+
+		if err := checkHttpManifest(manifest); err != nil {
+			return Extension{}, err
+		}
+	} else {
+		m, err := FetchManifest(context.Background(), metadata.Origin)
+		if err != nil {
+			return Extension{}, fmt.Errorf("failed to fetch manifest: %w", err)
+		}
+
+		if err := writeManifest(m, manifestPath); err != nil {
+			return Extension{}, err
+		}
+		manifest = m
+	}
+
+	return Extension{
+		Manifest:   manifest,
+		Type:       ExtensionTypeHttp,
+		Entrypoint: metadata.Origin,
+		Dir:        extensionDir,
+		DataDir:    dataDir,
 	}, nil
 }
 
@@ -289,21 +644,29 @@ func cacheManifest(entrypoint string, manifestPath string) (sunbeam.Manifest, er
 		return sunbeam.Manifest{}, fmt.Errorf("failed to extract manifest: %w", err)
 	}
 
+	if err := writeManifest(manifest, manifestPath); err != nil {
+		return sunbeam.Manifest{}, err
+	}
+
+	return manifest, nil
+}
+
+func writeManifest(manifest sunbeam.Manifest, manifestPath string) error {
 	if err := os.MkdirAll(filepath.Dir(manifestPath), 0755); err != nil {
-		return sunbeam.Manifest{}, fmt.Errorf("failed to create directory: %w", err)
+		return fmt.Errorf("failed to create directory: %w", err)
 	}
 
 	f, err := os.Create(manifestPath)
 	if err != nil {
-		return sunbeam.Manifest{}, fmt.Errorf("failed to create manifest: %w", err)
+		return fmt.Errorf("failed to create manifest: %w", err)
 	}
 	defer f.Close()
 
 	if err := json.NewEncoder(f).Encode(manifest); err != nil {
-		return sunbeam.Manifest{}, fmt.Errorf("failed to write manifest: %w", err)
+		return fmt.Errorf("failed to write manifest: %w", err)
 	}
 
-	return manifest, nil
+	return nil
 }
 
 func Upgrade(extensionConfig config.ExtensionConfig) error {
@@ -315,17 +678,17 @@ func Upgrade(extensionConfig config.ExtensionConfig) error {
 	extensionDir := filepath.Join(utils.CacheDir(), "extensions", hash)
 	manifestPath := filepath.Join(extensionDir, "manifest.json")
 	if IsRemote(extensionConfig.Origin) {
-		originUrl, err := url.Parse(extensionConfig.Origin)
+		metadata, err := FetchOrigin(context.Background(), extensionConfig.Origin, extensionDir)
 		if err != nil {
-			return fmt.Errorf("failed to parse origin: %w", err)
+			return err
 		}
 
-		entrypoint := filepath.Join(extensionDir, filepath.Base(originUrl.Path))
-		if err := DownloadEntrypoint(extensionConfig.Origin, entrypoint); err != nil {
-			return err
+		// the manifest of http extensions is cached when fetching the origin
+		if metadata.Type == ExtensionTypeHttp {
+			return nil
 		}
 
-		if _, err := cacheManifest(entrypoint, manifestPath); err != nil {
+		if _, err := cacheManifest(metadata.Entrypoint, manifestPath); err != nil {
 			return err
 		}
 
@@ -346,6 +709,10 @@ func Upgrade(extensionConfig config.ExtensionConfig) error {
 }
 
 func ExtractManifest(entrypoint string) (sunbeam.Manifest, error) {
+	if IsRemote(entrypoint) {
+		return FetchManifest(context.Background(), entrypoint)
+	}
+
 	entrypoint, err := filepath.Abs(entrypoint)
 	if err != nil {
 		return sunbeam.Manifest{}, err
@@ -369,14 +736,5 @@ func ExtractManifest(entrypoint string) (sunbeam.Manifest, error) {
 		return sunbeam.Manifest{}, err
 	}
 
-	if err := schemas.ValidateManifest(manifestBytes); err != nil {
-		return sunbeam.Manifest{}, err
-	}
-
-	var manifest sunbeam.Manifest
-	if err := json.Unmarshal(manifestBytes, &manifest); err != nil {
-		return sunbeam.Manifest{}, err
-	}
-
-	return manifest, nil
+	return parseManifest(manifestBytes)
 }
@@ -0,0 +1,124 @@
+package extensions
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pomdtr/sunbeam/internal/config"
+	"github.com/pomdtr/sunbeam/internal/utils"
+	"github.com/pomdtr/sunbeam/pkg/sunbeam"
+)
+
+func TestParseManifestPattern(t *testing.T) {
+	for _, tc := range []struct {
+		pattern string
+		valid   bool
+	}{
+		{pattern: `^\\d+$`, valid: true},
+		{pattern: `^[a-z]+(-[a-z]+)*$`, valid: true},
+		// lookaheads are valid in ECMA regexes, but not in RE2
+		{pattern: `^(?=.*\\d).+$`, valid: false},
+		{pattern: `[`, valid: false},
+	} {
+		manifest := `{"title": "Test", "commands": [{"name": "run", "title": "Run", "mode": "silent", "params": [{"name": "id", "title": "ID", "type": "string", "pattern": "` + tc.pattern + `"}]}]}`
+		_, err := parseManifest([]byte(manifest))
+		if tc.valid && err != nil {
+			t.Errorf("expected %s to be valid, got %v", tc.pattern, err)
+		} else if !tc.valid && err == nil {
+			t.Errorf("expected %s to be rejected", tc.pattern)
+		}
+	}
+}
+
+func TestPayloadDefaults(t *testing.T) {
+	extension := Extension{
+		Manifest: sunbeam.Manifest{
+			Preferences: []sunbeam.Input{
+				{Name: "limit", Type: sunbeam.InputNumber, Optional: true, Default: 10.0},
+			},
+			Commands: []sunbeam.CommandSpec{
+				{Name: "list", Params: []sunbeam.Input{
+					{Name: "page", Type: sunbeam.InputNumber, Optional: true, Default: 1.0},
+					{Name: "id", Type: sunbeam.InputNumber},
+				}},
+			},
+		},
+	}
+
+	payload, err := extension.payload(sunbeam.Payload{
+		Command:     "list",
+		Preferences: map[string]any{"limit": nil},
+		Params:      map[string]any{"page": nil, "id": 3},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if payload.Preferences["limit"] != 10.0 {
+		t.Errorf("expected the default preference, got %v", payload.Preferences["limit"])
+	}
+
+	if payload.Params["page"] != 1.0 {
+		t.Errorf("expected the default param, got %v", payload.Params["page"])
+	}
+
+	if _, err := extension.payload(sunbeam.Payload{
+		Command:     "list",
+		Preferences: map[string]any{},
+		Params:      map[string]any{"id": nil},
+	}); err == nil {
+		t.Error("expected a null required param to be rejected")
+	}
+}
+
+func TestMigrateDirs(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(dir, "cache"))
+	t.Setenv("XDG_DATA_HOME", filepath.Join(dir, "data"))
+
+	configPath := filepath.Join(dir, "sunbeam.json")
+	if err := os.WriteFile(configPath, []byte(`{"extensions": {"test": {"origin": "./test.sh"}}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the relative origin used to be resolved from the current directory
+	previousDir, err := DataDir("./test.sh")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.MkdirAll(previousDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(previousDir, "storage.json"), []byte(`{"key": "value"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := MigrateDirs(cfg); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+
+	dataDir, err := DataDir(filepath.Join(dir, "test.sh"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dataDir, "storage.json")); err != nil {
+		t.Errorf("expected the data dir to be moved: %v", err)
+	}
+
+	if _, err := os.Stat(previousDir); !os.IsNotExist(err) {
+		t.Errorf("expected the previous data dir to be removed, got %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(utils.CacheDir(), "extensions")); !os.IsNotExist(err) {
+		t.Errorf("expected missing cache dirs to be skipped, got %v", err)
+	}
+}
@@ -0,0 +1,165 @@
+package extensions
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pomdtr/sunbeam/internal/schemas"
+	"github.com/pomdtr/sunbeam/pkg/sunbeam"
+)
+
+// Http extensions are served by a remote endpoint. The manifest is fetched
+// using a GET request, and each payload is sent as the body of a POST request.
+
+const httpTimeout = 30 * time.Second
+
+var httpClient = &http.Client{
+	Timeout: httpTimeout,
+}
+
+func isManifestResponse(resp *http.Response) bool {
+	mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil {
+		return false
+	}
+
+	return mediaType == "application/json"
+}
+
+func FetchManifest(ctx context.Context, origin string) (sunbeam.Manifest, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, origin, nil)
+	if err != nil {
+		return sunbeam.Manifest{}, err
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return sunbeam.Manifest{}, httpError(err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return sunbeam.Manifest{}, fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return sunbeam.Manifest{}, statusError(resp, body)
+	}
+
+	return parseHttpManifest(body)
+}
+
+func parseHttpManifest(manifestBytes []byte) (sunbeam.Manifest, error) {
+	manifest, err := parseManifest(manifestBytes)
+	if err != nil {
+		return sunbeam.Manifest{}, err
+	}
+
+	if err := checkHttpManifest(manifest); err != nil {
+		return sunbeam.Manifest{}, err
+	}
+
+	return manifest, nil
+}
+
+// checkHttpManifest rejects the commands that need a local process.
+func checkHttpManifest(manifest sunbeam.Manifest) error {
+	for _, command := range manifest.Commands {
+		if command.Mode == sunbeam.CommandModeTTY {
+			return fmt.Errorf("command %s: http extensions can not have tty commands", command.Name)
+		}
+	}
+
+	return nil
+}
+
+func parseManifest(manifestBytes []byte) (sunbeam.Manifest, error) {
+	if err := schemas.ValidateManifest(manifestBytes); err != nil {
+		return sunbeam.Manifest{}, err
+	}
+
+	var manifest sunbeam.Manifest
+	if err := json.Unmarshal(manifestBytes, &manifest); err != nil {
+		return sunbeam.Manifest{}, err
+	}
+
+	// the persistent process is not attached to the terminal
+	if manifest.Persistent {
+		for _, command := range manifest.Commands {
+			if command.Mode == sunbeam.CommandModeTTY {
+				return sunbeam.Manifest{}, fmt.Errorf("command %s: persistent extensions can not have tty commands", command.Name)
+			}
+		}
+	}
+
+	return manifest, nil
+}
+
+func (e Extension) post(ctx context.Context, input sunbeam.Payload) ([]byte, error) {
+	inputBytes, err := json.Marshal(input)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.Entrypoint, bytes.NewReader(inputBytes))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	// header values can reference preferences, ex: "Bearer $token"
+	for key, value := range e.Manifest.Headers {
+		req.Header.Set(key, os.Expand(value, func(name string) string {
+			if preference, ok := input.Preferences[name]; ok && preference != nil {
+				return fmt.Sprint(preference)
+			}
+
+			return ""
+		}))
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, httpError(err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, statusError(resp, body)
+	}
+
+	return body, nil
+}
+
+func httpError(err error) error {
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return fmt.Errorf("request timed out after %s", httpTimeout)
+	}
+
+	return fmt.Errorf("request failed: %w", err)
+}
+
+func statusError(resp *http.Response, body []byte) error {
+	if message := strings.TrimSpace(string(body)); message != "" {
+		return fmt.Errorf("request failed: %s: %s", resp.Status, message)
+	}
+
+	return fmt.Errorf("request failed: %s", resp.Status)
+}
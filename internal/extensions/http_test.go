@@ -0,0 +1,133 @@
+package extensions
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pomdtr/sunbeam/pkg/sunbeam"
+)
+
+const httpManifest = `{
+	"title": "Test",
+	"preferences": [{"name": "token", "title": "Token", "type": "string"}],
+	"headers": {"Authorization": "Bearer $token"},
+	"commands": [
+		{"name": "list", "title": "List", "mode": "filter"},
+		{"name": "fail", "title": "Fail", "mode": "filter"}
+	]
+}`
+
+func newHttpServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = io.WriteString(w, httpManifest)
+			return
+		}
+
+		var payload sunbeam.Payload
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if payload.Command == "fail" {
+			http.Error(w, "database is down", http.StatusServiceUnavailable)
+			return
+		}
+
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"items": []map[string]any{{"title": r.Header.Get("Authorization")}},
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func loadHttpTestExtension(t *testing.T, origin string) Extension {
+	t.Helper()
+
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+
+	extension, err := LoadExtension(origin)
+	if err != nil {
+		t.Fatalf("failed to load extension: %v", err)
+	}
+
+	return extension
+}
+
+func TestHttpExtensionManifest(t *testing.T) {
+	server := newHttpServer(t)
+	extension := loadHttpTestExtension(t, server.URL)
+
+	if extension.Type != ExtensionTypeHttp {
+		t.Fatalf("expected an http extension, got %s", extension.Type)
+	}
+
+	if extension.Manifest.Title != "Test" || len(extension.Manifest.Commands) != 2 {
+		t.Errorf("unexpected manifest: %+v", extension.Manifest)
+	}
+}
+
+func TestHttpExtensionHeaders(t *testing.T) {
+	server := newHttpServer(t)
+	extension := loadHttpTestExtension(t, server.URL)
+
+	output, err := extension.Output(sunbeam.Payload{
+		Command:     "list",
+		Preferences: map[string]any{"token": "secret"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var list sunbeam.List
+	if err := json.Unmarshal(output, &list); err != nil {
+		t.Fatalf("invalid output: %v", err)
+	}
+
+	if len(list.Items) != 1 || list.Items[0].Title != "Bearer secret" {
+		t.Errorf("expected the header to reference the token, got %+v", list.Items)
+	}
+}
+
+func TestHttpExtensionStatusError(t *testing.T) {
+	server := newHttpServer(t)
+	extension := loadHttpTestExtension(t, server.URL)
+
+	_, err := extension.Output(sunbeam.Payload{
+		Command:     "fail",
+		Preferences: map[string]any{"token": "secret"},
+	})
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+
+	for _, expected := range []string{"503", "database is down"} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("expected %q in the error, got %q", expected, err)
+		}
+	}
+}
+
+func TestHttpManifestRejectsTTY(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"title": "Test", "commands": [{"name": "shell", "title": "Shell", "mode": "tty"}]}`)
+	}))
+	defer server.Close()
+
+	if _, err := FetchManifest(context.Background(), server.URL); err == nil {
+		t.Fatal("expected tty commands to be rejected")
+	}
+}
@@ -0,0 +1,142 @@
+package extensions
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pomdtr/sunbeam/pkg/sunbeam"
+)
+
+// Every invocation of an extension command is appended to the log of the
+// extension, as a json line. Once the log reaches maxLogSize, it is rotated
+// to a single backup file.
+
+const maxLogSize = 1 << 20
+
+type LogEntry struct {
+	Time     time.Time       `json:"time"`
+	Command  string          `json:"command"`
+	Payload  sunbeam.Payload `json:"payload"`
+	ExitCode int             `json:"exitCode"`
+	Duration time.Duration   `json:"duration"`
+	Stderr   string          `json:"stderr,omitempty"`
+	Error    string          `json:"error,omitempty"`
+}
+
+// commandError is returned when the command exits with a non-zero code.
+type commandError struct {
+	code   int
+	stderr string
+}
+
+func (e commandError) Error() string {
+	return fmt.Sprintf("command failed: %s", e.stderr)
+}
+
+func (e commandError) ExitCode() int {
+	return e.code
+}
+
+// LogPath returns the path of the extension log, or an empty string if the
+// extension has no cache directory.
+func (e Extension) LogPath() string {
+	if e.Dir == "" {
+		return ""
+	}
+
+	return filepath.Join(e.Dir, "extension.log")
+}
+
+// Log appends an invocation of the extension to its log. Errors are ignored,
+// logging should never prevent a command from running.
+func (e Extension) Log(input sunbeam.Payload, start time.Time, stderr string, err error) {
+	logPath := e.LogPath()
+	if logPath == "" {
+		return
+	}
+
+	// preferences often contain secrets
+	if len(input.Preferences) > 0 {
+		preferences := make(map[string]any)
+		for name := range input.Preferences {
+			preferences[name] = "***"
+		}
+		input.Preferences = preferences
+	}
+
+	entry := LogEntry{
+		Time:     start,
+		Command:  input.Command,
+		Payload:  input,
+		Duration: time.Since(start),
+		Stderr:   stderr,
+	}
+
+	if err != nil {
+		entry.Error = err.Error()
+		entry.ExitCode = -1
+
+		var exitErr interface{ ExitCode() int }
+		if errors.As(err, &exitErr) {
+			entry.ExitCode = exitErr.ExitCode()
+		}
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+
+	if info, err := os.Stat(logPath); err == nil && info.Size()+int64(len(line)) > maxLogSize {
+		_ = os.Rename(logPath, logPath+".1")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(logPath), 0755); err != nil {
+		return
+	}
+
+	f, err := os.OpenFile(logPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	_, _ = f.Write(append(line, '\n'))
+}
+
+// ReadLogs returns the entries of the log, oldest first, including the
+// rotated ones.
+func ReadLogs(logPath string) ([]LogEntry, error) {
+	var entries []LogEntry
+	for _, path := range []string{logPath + ".1", logPath} {
+		f, err := os.Open(path)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to open log: %w", err)
+		}
+
+		scanner := bufio.NewScanner(f)
+		scanner.Buffer(make([]byte, 0, 64*1024), maxLogSize)
+		for scanner.Scan() {
+			var entry LogEntry
+			if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+				continue
+			}
+
+			entries = append(entries, entry)
+		}
+		f.Close()
+
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("failed to read log: %w", err)
+		}
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,73 @@
+package extensions
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"time"
+)
+
+// DefaultTimeout applies to the commands which do not define their own
+// timeout. Commands have no timeout when it is zero.
+var DefaultTimeout time.Duration
+
+// killDelay is the time given to a process group to exit after SIGTERM,
+// before it is killed.
+const killDelay = 5 * time.Second
+
+// GroupCmd is a command running in its own process group. The group is
+// killed if it does not exit after SIGTERM, unless the command was waited
+// for in the meantime: the id of the group may belong to another one by then.
+type GroupCmd struct {
+	*exec.Cmd
+	killTimer *time.Timer
+}
+
+func (c *GroupCmd) Run() error {
+	defer c.stopKill()
+	return c.Cmd.Run()
+}
+
+func (c *GroupCmd) Output() ([]byte, error) {
+	defer c.stopKill()
+	return c.Cmd.Output()
+}
+
+func (c *GroupCmd) Wait() error {
+	defer c.stopKill()
+	return c.Cmd.Wait()
+}
+
+// stopKill is called once Wait returned, the timer is only set by Cancel,
+// which Wait waits for.
+func (c *GroupCmd) stopKill() {
+	if c.killTimer != nil {
+		c.killTimer.Stop()
+	}
+}
+
+type TimeoutError struct {
+	Timeout time.Duration
+}
+
+func (e TimeoutError) Error() string {
+	return fmt.Sprintf("timed out after %s", e.Timeout)
+}
+
+// Timeout returns the timeout of the command, or the default one.
+func (e Extension) Timeout(name string) time.Duration {
+	if command, ok := e.Command(name); ok && command.Timeout > 0 {
+		return time.Duration(command.Timeout) * time.Second
+	}
+
+	return DefaultTimeout
+}
+
+// WithTimeout is like context.WithTimeout, but a zero timeout never expires.
+func WithTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+
+	return context.WithTimeout(ctx, timeout)
+}
@@ -0,0 +1,14 @@
+//go:build !unix
+
+package extensions
+
+import (
+	"time"
+)
+
+// setProcessGroup has no process groups to rely on outside of unix: only
+// the command is killed once its context is done, its children are not.
+func setProcessGroup(cmd *GroupCmd) {
+	// the output pipes may be held open by the children
+	cmd.WaitDelay = killDelay + time.Second
+}
@@ -0,0 +1,31 @@
+//go:build unix
+
+package extensions
+
+import (
+	"syscall"
+	"time"
+)
+
+// setProcessGroup starts the command in its own process group. Once the
+// context of the command is done, the group receives SIGTERM, then SIGKILL
+// if it is still running after killDelay. This way, the children of the
+// command, such as the commands of a shell pipeline, are stopped too.
+func setProcessGroup(cmd *GroupCmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		pgid := -cmd.Process.Pid
+		if err := syscall.Kill(pgid, syscall.SIGTERM); err != nil {
+			return err
+		}
+
+		cmd.killTimer = time.AfterFunc(killDelay, func() {
+			_ = syscall.Kill(pgid, syscall.SIGKILL)
+		})
+
+		return nil
+	}
+
+	// the output pipes may be held open by the children
+	cmd.WaitDelay = killDelay + time.Second
+}
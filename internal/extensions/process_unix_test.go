@@ -0,0 +1,33 @@
+//go:build unix
+
+package extensions
+
+import (
+	"context"
+	"os/exec"
+	"testing"
+)
+
+func TestGroupKillIsStoppedOnWait(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cmd := &GroupCmd{Cmd: exec.CommandContext(ctx, "sleep", "10")}
+	setProcessGroup(cmd)
+
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	cancel()
+	if err := cmd.Wait(); err == nil {
+		t.Fatal("expected the command to be stopped")
+	}
+
+	if cmd.killTimer == nil {
+		t.Fatal("expected the kill of the group to be scheduled")
+	}
+
+	// Stop reports false if the timer was already stopped
+	if cmd.killTimer.Stop() {
+		t.Error("expected the kill to be stopped once the command exited")
+	}
+}
@@ -0,0 +1,162 @@
+package extensions
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pomdtr/sunbeam/internal/config"
+	"github.com/pomdtr/sunbeam/pkg/sunbeam"
+)
+
+// Preference values can reference an environment variable, a file, or the
+// output of a command, ex: {"$cmd": "pass show github"}. References are
+// resolved right before the extension is run.
+
+var (
+	commandOutputs   = make(map[string]string)
+	commandOutputsMu sync.Mutex
+)
+
+// IsPreferenceReference reports whether the value references another source.
+func IsPreferenceReference(value any) bool {
+	_, _, ok := preferenceReference(value)
+	return ok
+}
+
+func preferenceReference(value any) (string, string, bool) {
+	reference, ok := value.(map[string]any)
+	if !ok || len(reference) != 1 {
+		return "", "", false
+	}
+
+	for _, kind := range []string{"$env", "$file", "$cmd"} {
+		if target, ok := reference[kind].(string); ok {
+			return kind, target, true
+		}
+	}
+
+	return "", "", false
+}
+
+// ResolvePreferences returns a copy of the preferences, with the references
+// replaced by their value and the stored secrets loaded. Pages resolve their
+// preferences once, so that the extension and the exec actions expanding
+// them see the same values.
+func (e Extension) ResolvePreferences(preferences map[string]any) (map[string]any, error) {
+	resolved, err := e.resolvePreferences(preferences)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := e.LoadSecrets(resolved); err != nil {
+		return nil, err
+	}
+
+	return resolved, nil
+}
+
+// resolvePreferences returns a copy of the preferences, with the references
+// replaced by their value.
+func (e Extension) resolvePreferences(preferences map[string]any) (map[string]any, error) {
+	specs := make(map[string]sunbeam.Input)
+	for _, spec := range e.Manifest.Preferences {
+		specs[spec.Name] = spec
+	}
+
+	resolved := make(map[string]any)
+	for name, value := range preferences {
+		kind, target, ok := preferenceReference(value)
+		if !ok {
+			resolved[name] = value
+			continue
+		}
+
+		s, err := resolveReference(kind, target)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve preference %s: %w", name, err)
+		}
+
+		switch specs[name].Type {
+		case sunbeam.InputBoolean:
+			b, err := strconv.ParseBool(s)
+			if err != nil {
+				return nil, fmt.Errorf("failed to resolve preference %s: %w", name, err)
+			}
+			resolved[name] = b
+		case sunbeam.InputNumber:
+			n, err := strconv.ParseFloat(s, 64)
+			if err != nil {
+				return nil, fmt.Errorf("failed to resolve preference %s: %w", name, err)
+			}
+			resolved[name] = n
+		default:
+			resolved[name] = s
+		}
+	}
+
+	return resolved, nil
+}
+
+func resolveReference(kind string, target string) (string, error) {
+	switch kind {
+	case "$env":
+		value, ok := os.LookupEnv(target)
+		if !ok {
+			return "", fmt.Errorf("environment variable %s is not set", target)
+		}
+
+		return value, nil
+	case "$file":
+		path := target
+		if strings.HasPrefix(path, "~/") {
+			path = filepath.Join(os.Getenv("HOME"), path[2:])
+		} else if !filepath.IsAbs(path) {
+			path = filepath.Join(filepath.Dir(config.Path), path)
+		}
+
+		bts, err := os.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+
+		return strings.TrimRight(string(bts), "\r\n"), nil
+	case "$cmd":
+		return commandOutput(target)
+	default:
+		return "", fmt.Errorf("unknown reference %s", kind)
+	}
+}
+
+// commandOutput runs the command once per session, password managers can
+// prompt the user on each call.
+func commandOutput(command string) (string, error) {
+	commandOutputsMu.Lock()
+	defer commandOutputsMu.Unlock()
+
+	if output, ok := commandOutputs[command]; ok {
+		return output, nil
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.Command("sh", "-c", command)
+	cmd.Dir = filepath.Dir(config.Path)
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("command %q failed: %s", command, msg)
+		}
+
+		return "", fmt.Errorf("command %q failed: %w", command, err)
+	}
+
+	commandOutputs[command] = strings.TrimRight(string(output), "\r\n")
+	return commandOutputs[command], nil
+}
@@ -0,0 +1,272 @@
+package extensions
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/acarl005/stripansi"
+	"github.com/pomdtr/sunbeam/pkg/sunbeam"
+)
+
+// Persistent extensions are started once per session. Sunbeam sends one
+// json-rpc request per line on the process stdin, and reads the responses
+// from its stdout.
+
+var ErrProcessExited = errors.New("extension process exited")
+
+// stopDelay is the time given to a process to exit once its stdin is closed.
+const stopDelay = time.Second
+
+var (
+	processes   = make(map[string]*Process)
+	processesMu sync.Mutex
+)
+
+type rpcRequest struct {
+	Version string `json:"jsonrpc"`
+	ID      int    `json:"id,omitempty"`
+	Method  string `json:"method"`
+	Params  any    `json:"params,omitempty"`
+}
+
+type rpcResponse struct {
+	Version string          `json:"jsonrpc"`
+	ID      int             `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type Process struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stderr *tailBuffer
+
+	mu      sync.Mutex
+	nextID  int
+	pending map[int]chan rpcResponse
+
+	done chan struct{}
+	err  error
+}
+
+func (e Extension) process() (*Process, error) {
+	processesMu.Lock()
+	defer processesMu.Unlock()
+
+	if process, ok := processes[e.Entrypoint]; ok {
+		select {
+		case <-process.done:
+		default:
+			return process, nil
+		}
+	}
+
+	process, err := StartProcess(e.Entrypoint, e.environ())
+	if err != nil {
+		return nil, err
+	}
+
+	processes[e.Entrypoint] = process
+	return process, nil
+}
+
+// StopProcess stops the persistent process of the extension, if it is running.
+// It will be restarted on the next call.
+func StopProcess(entrypoint string) {
+	processesMu.Lock()
+	defer processesMu.Unlock()
+
+	if process, ok := processes[entrypoint]; ok {
+		process.Stop()
+		delete(processes, entrypoint)
+	}
+}
+
+// StopAll stops the persistent processes, it must be called before exiting.
+func StopAll() {
+	processesMu.Lock()
+	defer processesMu.Unlock()
+
+	for entrypoint, process := range processes {
+		process.Stop()
+		delete(processes, entrypoint)
+	}
+}
+
+func StartProcess(entrypoint string, env []string) (*Process, error) {
+	cmd := exec.Command(entrypoint)
+	cmd.Dir = filepath.Dir(entrypoint)
+	cmd.Env = append(env, "SUNBEAM_RPC=1")
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	stderr := &tailBuffer{size: 4096}
+	cmd.Stderr = stderr
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start extension: %w", err)
+	}
+
+	process := &Process{
+		cmd:     cmd,
+		stdin:   stdin,
+		stderr:  stderr,
+		pending: make(map[int]chan rpcResponse),
+		done:    make(chan struct{}),
+	}
+
+	go process.read(stdout)
+	return process, nil
+}
+
+func (p *Process) read(stdout io.Reader) {
+	reader := bufio.NewReader(stdout)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(strings.TrimSpace(string(line))) > 0 {
+			var res rpcResponse
+			if err := json.Unmarshal(line, &res); err == nil {
+				p.mu.Lock()
+				if ch, ok := p.pending[res.ID]; ok {
+					delete(p.pending, res.ID)
+					ch <- res
+				}
+				p.mu.Unlock()
+			}
+		}
+
+		if err != nil {
+			break
+		}
+	}
+
+	err := p.cmd.Wait()
+	if stderr := strings.TrimSpace(stripansi.Strip(p.stderr.String())); stderr != "" {
+		p.err = fmt.Errorf("%w: %s", ErrProcessExited, stderr)
+	} else if err != nil {
+		p.err = fmt.Errorf("%w: %s", ErrProcessExited, err)
+	} else {
+		p.err = ErrProcessExited
+	}
+
+	close(p.done)
+}
+
+// Call sends the payload to the process, and waits for the response.
+// If the context is cancelled, a cancel notification is sent to the process.
+func (p *Process) Call(ctx context.Context, payload sunbeam.Payload) ([]byte, error) {
+	ch := make(chan rpcResponse, 1)
+
+	p.mu.Lock()
+	p.nextID++
+	id := p.nextID
+	p.pending[id] = ch
+	err := p.send(rpcRequest{Version: "2.0", ID: id, Method: "run", Params: payload})
+	p.mu.Unlock()
+
+	if err != nil {
+		p.cancel(id)
+		select {
+		case <-p.done:
+			return nil, p.err
+		default:
+			return nil, fmt.Errorf("failed to send request: %w", err)
+		}
+	}
+
+	select {
+	case res := <-ch:
+		if res.Error != nil {
+			return nil, fmt.Errorf("command failed: %s", res.Error.Message)
+		}
+
+		return res.Result, nil
+	case <-p.done:
+		return nil, p.err
+	case <-ctx.Done():
+		p.mu.Lock()
+		_ = p.send(rpcRequest{Version: "2.0", Method: "$/cancelRequest", Params: map[string]int{"id": id}})
+		p.mu.Unlock()
+		p.cancel(id)
+
+		return nil, ctx.Err()
+	}
+}
+
+func (p *Process) cancel(id int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	delete(p.pending, id)
+}
+
+// send must be called with the lock held
+func (p *Process) send(req rpcRequest) error {
+	bts, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+
+	_, err = p.stdin.Write(append(bts, '\n'))
+	return err
+}
+
+// Stop closes the process stdin, and kills it if it is still running after stopDelay.
+func (p *Process) Stop() {
+	_ = p.stdin.Close()
+
+	select {
+	case <-p.done:
+	case <-time.After(stopDelay):
+		_ = p.cmd.Process.Kill()
+		<-p.done
+	}
+}
+
+// tailBuffer only keeps the last bytes written to it
+type tailBuffer struct {
+	mu   sync.Mutex
+	size int
+	buf  []byte
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.buf = append(b.buf, p...)
+	if len(b.buf) > b.size {
+		b.buf = b.buf[len(b.buf)-b.size:]
+	}
+
+	return len(p), nil
+}
+
+func (b *tailBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return string(b.buf)
+}
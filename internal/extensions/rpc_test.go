@@ -0,0 +1,217 @@
+package extensions
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pomdtr/sunbeam/pkg/sunbeam"
+)
+
+// The test binary acts as a persistent extension when SUNBEAM_RPC_HELPER is set.
+func TestMain(m *testing.M) {
+	if os.Getenv("SUNBEAM_RPC_HELPER") == "1" {
+		serveHelper()
+		os.Exit(0)
+	}
+
+	os.Exit(m.Run())
+}
+
+func serveHelper() {
+	var mu sync.Mutex
+	write := func(line string) {
+		mu.Lock()
+		defer mu.Unlock()
+		fmt.Println(line)
+	}
+
+	var cancelled []int
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		var req struct {
+			ID     int             `json:"id"`
+			Method string          `json:"method"`
+			Params json.RawMessage `json:"params"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			continue
+		}
+
+		if req.Method == "$/cancelRequest" {
+			var params struct {
+				ID int `json:"id"`
+			}
+			_ = json.Unmarshal(req.Params, &params)
+
+			mu.Lock()
+			cancelled = append(cancelled, params.ID)
+			mu.Unlock()
+			continue
+		}
+
+		var payload sunbeam.Payload
+		_ = json.Unmarshal(req.Params, &payload)
+
+		respond := func(result any) {
+			bts, _ := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": req.ID, "result": result})
+			write(string(bts))
+		}
+
+		switch payload.Command {
+		case "slow":
+			go func(respond func(any)) {
+				time.Sleep(200 * time.Millisecond)
+				respond(payload.Command)
+			}(respond)
+		case "noisy":
+			// blank and invalid lines are ignored
+			write("")
+			write("not json")
+			respond("line 1\nline 2")
+		case "fail":
+			bts, _ := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": req.ID, "error": map[string]any{"code": 1, "message": "something went wrong"}})
+			write(string(bts))
+		case "block":
+			// never answered
+		case "cancelled":
+			mu.Lock()
+			ids := append([]int(nil), cancelled...)
+			mu.Unlock()
+			respond(ids)
+		case "exit":
+			fmt.Fprintln(os.Stderr, "boom")
+			os.Exit(2)
+		default:
+			respond(payload.Command)
+		}
+	}
+}
+
+func startHelper(t *testing.T) *Process {
+	t.Helper()
+
+	process, err := StartProcess(os.Args[0], append(os.Environ(), "SUNBEAM_RPC_HELPER=1"))
+	if err != nil {
+		t.Fatalf("failed to start process: %v", err)
+	}
+	t.Cleanup(process.Stop)
+
+	return process
+}
+
+func call(t *testing.T, process *Process, command string) string {
+	t.Helper()
+
+	output, err := process.Call(context.Background(), sunbeam.Payload{Command: command})
+	if err != nil {
+		t.Fatalf("call %s failed: %v", command, err)
+	}
+
+	var result string
+	if err := json.Unmarshal(output, &result); err != nil {
+		t.Fatalf("invalid result %s: %v", output, err)
+	}
+
+	return result
+}
+
+func TestProcessCorrelatesResponses(t *testing.T) {
+	process := startHelper(t)
+
+	// the slow response is written after the fast one
+	results := make(map[string]string)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, command := range []string{"slow", "fast"} {
+		wg.Add(1)
+		go func(command string) {
+			defer wg.Done()
+			result := call(t, process, command)
+
+			mu.Lock()
+			results[command] = result
+			mu.Unlock()
+		}(command)
+	}
+	wg.Wait()
+
+	for _, command := range []string{"slow", "fast"} {
+		if results[command] != command {
+			t.Errorf("expected %q, got %q", command, results[command])
+		}
+	}
+}
+
+func TestProcessFraming(t *testing.T) {
+	process := startHelper(t)
+
+	if result := call(t, process, "noisy"); result != "line 1\nline 2" {
+		t.Errorf("unexpected result: %q", result)
+	}
+
+	if result := call(t, process, "next"); result != "next" {
+		t.Errorf("unexpected result: %q", result)
+	}
+}
+
+func TestProcessError(t *testing.T) {
+	process := startHelper(t)
+
+	_, err := process.Call(context.Background(), sunbeam.Payload{Command: "fail"})
+	if err == nil || !strings.Contains(err.Error(), "something went wrong") {
+		t.Fatalf("expected the error of the response, got %v", err)
+	}
+}
+
+func TestProcessCancel(t *testing.T) {
+	process := startHelper(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if _, err := process.Call(ctx, sunbeam.Payload{Command: "block"}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the call to time out, got %v", err)
+	}
+
+	output, err := process.Call(context.Background(), sunbeam.Payload{Command: "cancelled"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var cancelled []int
+	if err := json.Unmarshal(output, &cancelled); err != nil {
+		t.Fatalf("invalid result: %v", err)
+	}
+
+	if len(cancelled) != 1 || cancelled[0] != 1 {
+		t.Errorf("expected request 1 to be cancelled, got %v", cancelled)
+	}
+}
+
+func TestProcessExit(t *testing.T) {
+	process := startHelper(t)
+
+	_, err := process.Call(context.Background(), sunbeam.Payload{Command: "exit"})
+	if !errors.Is(err, ErrProcessExited) {
+		t.Fatalf("expected ErrProcessExited, got %v", err)
+	}
+
+	if !strings.Contains(err.Error(), "boom") {
+		t.Errorf("expected the stderr of the process in the error, got %v", err)
+	}
+}
+
+func TestPersistentManifestRejectsTTY(t *testing.T) {
+	manifest := `{"title": "Test", "persistent": true, "commands": [{"name": "shell", "title": "Shell", "mode": "tty"}]}`
+	if _, err := parseManifest([]byte(manifest)); err == nil {
+		t.Fatal("expected tty commands to be rejected")
+	}
+}
@@ -0,0 +1,385 @@
+package extensions
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pomdtr/sunbeam/internal/utils"
+	"github.com/pomdtr/sunbeam/pkg/sunbeam"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// The values of the secret preferences are stored encrypted in the data
+// directory of the extension, instead of the config. The key is read from a
+// key file, generated on first use, or derived from a passphrase if
+// SUNBEAM_SECRETS_PASSPHRASE is set.
+
+const (
+	kdfPBKDF2        = "pbkdf2-sha256"
+	pbkdf2Iterations = 200000
+)
+
+var ErrWrongSecretKey = errors.New("failed to decrypt secrets, check your passphrase or key file")
+
+type secretsFile struct {
+	KDF    string            `json:"kdf,omitempty"`
+	Salt   string            `json:"salt,omitempty"`
+	Values map[string]string `json:"values"`
+}
+
+// Secrets is the encrypted store of the secret preferences of an extension.
+type Secrets struct {
+	path string
+}
+
+func NewSecrets(dataDir string) Secrets {
+	return Secrets{path: filepath.Join(dataDir, "secrets.json")}
+}
+
+func (e Extension) Secrets() Secrets {
+	return NewSecrets(e.DataDir)
+}
+
+// SecretsKeyFile returns the path of the key file used to encrypt the secrets.
+func SecretsKeyFile() string {
+	if env, ok := os.LookupEnv("SUNBEAM_SECRETS_KEY_FILE"); ok {
+		return env
+	}
+
+	return filepath.Join(utils.DataDir(), "secrets.key")
+}
+
+func (s Secrets) load() (secretsFile, error) {
+	file := secretsFile{Values: make(map[string]string)}
+	bts, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return file, nil
+	} else if err != nil {
+		return secretsFile{}, fmt.Errorf("failed to read secrets: %w", err)
+	}
+
+	if err := json.Unmarshal(bts, &file); err != nil {
+		return secretsFile{}, fmt.Errorf("failed to decode secrets: %w", err)
+	}
+
+	if file.Values == nil {
+		file.Values = make(map[string]string)
+	}
+
+	return file, nil
+}
+
+// Names returns the names of the stored secrets. The values are not decrypted.
+func (s Secrets) Names() ([]string, error) {
+	file, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(file.Values))
+	for name := range file.Values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names, nil
+}
+
+func (s Secrets) Get(name string) (string, bool, error) {
+	file, err := s.load()
+	if err != nil {
+		return "", false, err
+	}
+
+	ciphertext, ok := file.Values[name]
+	if !ok {
+		return "", false, nil
+	}
+
+	gcm, err := file.cipher()
+	if err != nil {
+		return "", false, err
+	}
+
+	value, err := decrypt(gcm, name, ciphertext)
+	if err != nil {
+		return "", false, err
+	}
+
+	return value, true, nil
+}
+
+// Set stores the value of a secret. The other secrets are encrypted again,
+// so that the whole store uses the current key.
+func (s Secrets) Set(name string, value string) error {
+	values, err := s.decryptAll()
+	if err != nil {
+		return err
+	}
+
+	values[name] = value
+	return s.save(values)
+}
+
+// Delete removes a secret. It reports whether the secret existed.
+func (s Secrets) Delete(name string) (bool, error) {
+	file, err := s.load()
+	if err != nil {
+		return false, err
+	}
+
+	if _, ok := file.Values[name]; !ok {
+		return false, nil
+	}
+
+	delete(file.Values, name)
+	return true, s.write(file)
+}
+
+func (s Secrets) decryptAll() (map[string]string, error) {
+	file, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]string)
+	if len(file.Values) == 0 {
+		return values, nil
+	}
+
+	gcm, err := file.cipher()
+	if err != nil {
+		return nil, err
+	}
+
+	for name, ciphertext := range file.Values {
+		value, err := decrypt(gcm, name, ciphertext)
+		if err != nil {
+			return nil, err
+		}
+
+		values[name] = value
+	}
+
+	return values, nil
+}
+
+func (s Secrets) save(values map[string]string) error {
+	file := secretsFile{Values: make(map[string]string)}
+	if _, ok := os.LookupEnv("SUNBEAM_SECRETS_PASSPHRASE"); ok {
+		salt := make([]byte, 16)
+		if _, err := rand.Read(salt); err != nil {
+			return err
+		}
+
+		file.KDF = kdfPBKDF2
+		file.Salt = base64.StdEncoding.EncodeToString(salt)
+	}
+
+	gcm, err := file.cipher()
+	if err != nil {
+		return err
+	}
+
+	for name, value := range values {
+		nonce := make([]byte, gcm.NonceSize())
+		if _, err := rand.Read(nonce); err != nil {
+			return err
+		}
+
+		// the name is authenticated, so that values can not be swapped
+		ciphertext := gcm.Seal(nonce, nonce, []byte(value), []byte(name))
+		file.Values[name] = base64.StdEncoding.EncodeToString(ciphertext)
+	}
+
+	return s.write(file)
+}
+
+func (s Secrets) write(file secretsFile) error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return err
+	}
+
+	bts, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, bts, 0600); err != nil {
+		return fmt.Errorf("failed to write secrets: %w", err)
+	}
+
+	return os.Rename(tmp, s.path)
+}
+
+func (f secretsFile) cipher() (cipher.AEAD, error) {
+	key, err := f.key()
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+var derivedKeys sync.Map
+
+func (f secretsFile) key() ([]byte, error) {
+	if f.KDF == "" {
+		return loadKeyFile()
+	}
+
+	if f.KDF != kdfPBKDF2 {
+		return nil, fmt.Errorf("unsupported key derivation function: %s", f.KDF)
+	}
+
+	passphrase, ok := os.LookupEnv("SUNBEAM_SECRETS_PASSPHRASE")
+	if !ok {
+		return nil, fmt.Errorf("secrets are encrypted with a passphrase, set SUNBEAM_SECRETS_PASSPHRASE")
+	}
+
+	salt, err := base64.StdEncoding.DecodeString(f.Salt)
+	if err != nil {
+		return nil, fmt.Errorf("invalid salt: %w", err)
+	}
+
+	// deriving the key is slow on purpose, it is only done once per process
+	cacheKey := fmt.Sprintf("%s:%s", f.Salt, passphrase)
+	if key, ok := derivedKeys.Load(cacheKey); ok {
+		return key.([]byte), nil
+	}
+
+	key := pbkdf2.Key([]byte(passphrase), salt, pbkdf2Iterations, 32, sha256.New)
+	derivedKeys.Store(cacheKey, key)
+	return key, nil
+}
+
+// loadKeyFile reads the key file, or generates it if the default one does not exist yet.
+func loadKeyFile() ([]byte, error) {
+	keyFile := SecretsKeyFile()
+	bts, err := os.ReadFile(keyFile)
+	if os.IsNotExist(err) {
+		if _, ok := os.LookupEnv("SUNBEAM_SECRETS_KEY_FILE"); ok {
+			return nil, fmt.Errorf("key file not found: %s", keyFile)
+		}
+
+		key := make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, err
+		}
+
+		if err := os.MkdirAll(filepath.Dir(keyFile), 0700); err != nil {
+			return nil, err
+		}
+
+		if err := os.WriteFile(keyFile, []byte(hex.EncodeToString(key)+"\n"), 0600); err != nil {
+			return nil, fmt.Errorf("failed to write key file: %w", err)
+		}
+
+		return key, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read key file: %w", err)
+	}
+
+	key, err := hex.DecodeString(strings.TrimSpace(string(bts)))
+	if err != nil || len(key) != 32 {
+		return nil, fmt.Errorf("invalid key file %s, expected 32 hex encoded bytes", keyFile)
+	}
+
+	return key, nil
+}
+
+func decrypt(gcm cipher.AEAD, name string, value string) (string, error) {
+	ciphertext, err := base64.StdEncoding.DecodeString(value)
+	if err != nil || len(ciphertext) < gcm.NonceSize() {
+		return "", fmt.Errorf("invalid secret %s", name)
+	}
+
+	nonce, ciphertext := ciphertext[:gcm.NonceSize()], ciphertext[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, []byte(name))
+	if err != nil {
+		return "", ErrWrongSecretKey
+	}
+
+	return string(plaintext), nil
+}
+
+// LoadSecrets fills the secret preferences missing from preferences with
+// their stored value.
+func (e Extension) LoadSecrets(preferences map[string]any) error {
+	for _, spec := range e.Manifest.Preferences {
+		if spec.Type != sunbeam.InputSecret || e.DataDir == "" {
+			continue
+		}
+
+		if value, ok := preferences[spec.Name]; ok && value != nil {
+			continue
+		}
+
+		value, ok, err := e.Secrets().Get(spec.Name)
+		if err != nil {
+			return err
+		}
+
+		if ok {
+			preferences[spec.Name] = value
+		}
+	}
+
+	return nil
+}
+
+// SaveSecrets moves the values of the secret preferences to the encrypted
+// store, and returns the other values, to be saved in the config.
+func (e Extension) SaveSecrets(preferences map[string]any) (map[string]any, error) {
+	if e.DataDir == "" {
+		return nil, fmt.Errorf("extension has no data directory")
+	}
+
+	others := make(map[string]any)
+	for name, value := range preferences {
+		others[name] = value
+	}
+
+	for _, spec := range e.Manifest.Preferences {
+		if spec.Type != sunbeam.InputSecret {
+			continue
+		}
+
+		value, ok := others[spec.Name]
+		if !ok {
+			continue
+		}
+		delete(others, spec.Name)
+
+		if value == nil || value == "" {
+			if _, err := e.Secrets().Delete(spec.Name); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if err := e.Secrets().Set(spec.Name, fmt.Sprint(value)); err != nil {
+			return nil, err
+		}
+	}
+
+	return others, nil
+}
@@ -0,0 +1,141 @@
+package extensions
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// setupSecrets uses a new key file, and returns the store of an extension.
+func setupSecrets(t *testing.T) Secrets {
+	t.Helper()
+
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, "secrets.key")
+	if err := os.WriteFile(keyFile, []byte(strings.Repeat("ab", 32)+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("SUNBEAM_SECRETS_KEY_FILE", keyFile)
+
+	return NewSecrets(filepath.Join(dir, "data"))
+}
+
+func TestSecretsRoundTrip(t *testing.T) {
+	for _, passphrase := range []string{"", "correct horse battery staple"} {
+		secrets := setupSecrets(t)
+		if passphrase != "" {
+			t.Setenv("SUNBEAM_SECRETS_PASSPHRASE", passphrase)
+		}
+
+		if err := secrets.Set("token", "s3cr3t"); err != nil {
+			t.Fatalf("failed to set secret: %v", err)
+		}
+
+		if err := secrets.Set("password", "hunter2"); err != nil {
+			t.Fatalf("failed to set secret: %v", err)
+		}
+
+		value, ok, err := secrets.Get("token")
+		if err != nil || !ok || value != "s3cr3t" {
+			t.Fatalf("expected the stored secret, got %q, %v, %v", value, ok, err)
+		}
+
+		bts, err := os.ReadFile(secrets.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if strings.Contains(string(bts), "s3cr3t") {
+			t.Error("expected the secret to be encrypted")
+		}
+
+		names, err := secrets.Names()
+		if err != nil || strings.Join(names, ",") != "password,token" {
+			t.Errorf("expected the names of the secrets, got %v, %v", names, err)
+		}
+
+		if deleted, err := secrets.Delete("token"); err != nil || !deleted {
+			t.Errorf("expected the secret to be deleted, got %v, %v", deleted, err)
+		}
+
+		if deleted, err := secrets.Delete("token"); err != nil || deleted {
+			t.Errorf("expected a missing secret not to be deleted, got %v, %v", deleted, err)
+		}
+
+		if _, ok, err := secrets.Get("token"); err != nil || ok {
+			t.Errorf("expected the secret to be missing, got %v, %v", ok, err)
+		}
+
+		if value, _, err := secrets.Get("password"); err != nil || value != "hunter2" {
+			t.Errorf("expected the other secret to be kept, got %q, %v", value, err)
+		}
+	}
+}
+
+func TestSecretsWrongKey(t *testing.T) {
+	t.Run("key file", func(t *testing.T) {
+		secrets := setupSecrets(t)
+		if err := secrets.Set("token", "s3cr3t"); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(os.Getenv("SUNBEAM_SECRETS_KEY_FILE"), []byte(strings.Repeat("cd", 32)), 0600); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, _, err := secrets.Get("token"); !errors.Is(err, ErrWrongSecretKey) {
+			t.Errorf("expected ErrWrongSecretKey, got %v", err)
+		}
+	})
+
+	t.Run("passphrase", func(t *testing.T) {
+		secrets := setupSecrets(t)
+		t.Setenv("SUNBEAM_SECRETS_PASSPHRASE", "right")
+		if err := secrets.Set("token", "s3cr3t"); err != nil {
+			t.Fatal(err)
+		}
+
+		t.Setenv("SUNBEAM_SECRETS_PASSPHRASE", "wrong")
+		if _, _, err := secrets.Get("token"); !errors.Is(err, ErrWrongSecretKey) {
+			t.Errorf("expected ErrWrongSecretKey, got %v", err)
+		}
+
+		if err := secrets.Set("other", "value"); !errors.Is(err, ErrWrongSecretKey) {
+			t.Errorf("expected the store not to be encrypted again with the wrong passphrase, got %v", err)
+		}
+	})
+}
+
+func TestSecretsSwappedValues(t *testing.T) {
+	secrets := setupSecrets(t)
+	if err := secrets.Set("token", "s3cr3t"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := secrets.Set("username", "alice"); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := secrets.load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the ciphertexts are valid, but are stored under the wrong names
+	file.Values["token"], file.Values["username"] = file.Values["username"], file.Values["token"]
+	bts, err := json.Marshal(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(secrets.path, bts, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if value, _, err := secrets.Get("token"); err == nil {
+		t.Errorf("expected the swapped value to be rejected, got %q", value)
+	}
+}
@@ -0,0 +1,124 @@
+package extensions
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/pomdtr/sunbeam/internal/utils"
+)
+
+// Each extension gets a data directory, which is kept across upgrades and
+// cache clears. The storage is a key-value store persisted in this directory.
+
+// DataDir returns the data directory of the extension installed from origin.
+func DataDir(origin string) (string, error) {
+	hash, err := Hash(origin)
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(utils.DataDir(), "extensions", hash), nil
+}
+
+// Storage is the key-value store of an extension.
+type Storage struct {
+	path string
+}
+
+// NewStorage returns the storage persisted in dataDir.
+func NewStorage(dataDir string) Storage {
+	return Storage{path: filepath.Join(dataDir, "storage.json")}
+}
+
+func (e Extension) Storage() Storage {
+	return NewStorage(e.DataDir)
+}
+
+func (s Storage) load() (map[string]string, error) {
+	values := make(map[string]string)
+	bts, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return values, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read storage: %w", err)
+	}
+
+	if err := json.Unmarshal(bts, &values); err != nil {
+		return nil, fmt.Errorf("failed to decode storage: %w", err)
+	}
+
+	return values, nil
+}
+
+func (s Storage) save(values map[string]string) error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return err
+	}
+
+	bts, err := json.MarshalIndent(values, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// the file is replaced atomically, so readers never see a partial write
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, bts, 0600); err != nil {
+		return fmt.Errorf("failed to write storage: %w", err)
+	}
+
+	return os.Rename(tmp, s.path)
+}
+
+func (s Storage) Get(key string) (string, bool, error) {
+	values, err := s.load()
+	if err != nil {
+		return "", false, err
+	}
+
+	value, ok := values[key]
+	return value, ok, nil
+}
+
+func (s Storage) Set(key string, value string) error {
+	values, err := s.load()
+	if err != nil {
+		return err
+	}
+
+	values[key] = value
+	return s.save(values)
+}
+
+// Delete removes the key from the storage. It reports whether the key existed.
+func (s Storage) Delete(key string) (bool, error) {
+	values, err := s.load()
+	if err != nil {
+		return false, err
+	}
+
+	if _, ok := values[key]; !ok {
+		return false, nil
+	}
+
+	delete(values, key)
+	return true, s.save(values)
+}
+
+// Keys returns the keys of the storage, sorted alphabetically.
+func (s Storage) Keys() ([]string, error) {
+	values, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys, nil
+}
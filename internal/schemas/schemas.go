@@ -4,6 +4,7 @@ import (
 	"embed"
 	"encoding/json"
 	"fmt"
+	"regexp"
 
 	"github.com/santhosh-tekuri/jsonschema/v5"
 )
@@ -25,6 +26,16 @@ var schemaUrls = []string{
 func init() {
 	compiler := jsonschema.NewCompiler()
 	compiler.Draft = jsonschema.Draft7
+	// input patterns use the go regexp syntax, not the ECMA one
+	compiler.Formats["re2"] = func(v interface{}) bool {
+		pattern, ok := v.(string)
+		if !ok {
+			return true
+		}
+
+		_, err := regexp.Compile(pattern)
+		return err == nil
+	}
 
 	for _, url := range schemaUrls {
 		schema, err := embedFS.Open(url)
@@ -46,6 +57,12 @@ func init() {
 
 		schemas[url] = schema
 	}
+
+	item, err := compiler.Compile("list.schema.json#/definitions/item")
+	if err != nil {
+		panic(err)
+	}
+	schemas["listitem"] = item
 }
 
 func formatValidationError(ve *jsonschema.ValidationError) string {
@@ -80,6 +97,10 @@ func ValidateList(input []byte) error {
 	return validateSchema("list.schema.json", input)
 }
 
+func ValidateListItem(input []byte) error {
+	return validateSchema("listitem", input)
+}
+
 func ValidateManifest(input []byte) error {
 	return validateSchema("manifest.schema.json", input)
 }
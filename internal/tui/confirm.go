@@ -0,0 +1,129 @@
+package tui
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/pomdtr/sunbeam/pkg/sunbeam"
+)
+
+// Confirm asks the user to confirm an action before dispatching it to the
+// page below.
+type Confirm struct {
+	width, height int
+	action        sunbeam.Action
+	step          *sequenceStep
+	confirmed     bool
+}
+
+func NewConfirm(action sunbeam.Action) *Confirm {
+	return &Confirm{
+		action: action,
+	}
+}
+
+// DispatchAction sends the action to the current page, after asking for a
+// confirmation if the action requires it.
+func DispatchAction(action sunbeam.Action) tea.Cmd {
+	return dispatchActionMsg(action, nil)
+}
+
+// dispatchActionMsg is DispatchAction for actions which may be run as a step
+// of a sequence.
+func dispatchActionMsg(action sunbeam.Action, step *sequenceStep) tea.Cmd {
+	if action.Confirm != nil && action.Confirm.Required() {
+		confirm := NewConfirm(action)
+		confirm.step = step
+		return PushPageCmd(confirm)
+	}
+
+	return func() tea.Msg {
+		return actionMsg(action, step)
+	}
+}
+
+func actionMsg(action sunbeam.Action, step *sequenceStep) tea.Msg {
+	if step != nil {
+		return stepActionMsg{action: action, step: step}
+	}
+
+	return action
+}
+
+func (c *Confirm) Init() tea.Cmd {
+	return nil
+}
+
+func (c *Confirm) Focus() tea.Cmd {
+	return nil
+}
+
+func (c *Confirm) Blur() tea.Cmd {
+	return nil
+}
+
+func (c *Confirm) SetSize(width, height int) {
+	c.width, c.height = width, height
+}
+
+func (c *Confirm) Update(msg tea.Msg) (Page, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "left", "right", "tab", "shift+tab":
+			c.confirmed = !c.confirmed
+		case "y":
+			return c, c.submit()
+		case "n", "esc", "q":
+			return c, PopPageCmd
+		case "enter":
+			if !c.confirmed {
+				return c, PopPageCmd
+			}
+
+			return c, c.submit()
+		}
+	}
+
+	return c, nil
+}
+
+func (c *Confirm) submit() tea.Cmd {
+	action, step := c.action, c.step
+	return tea.Sequence(PopPageCmd, func() tea.Msg {
+		return actionMsg(action, step)
+	})
+}
+
+func (c *Confirm) View() string {
+	message := "Are you sure?"
+	if c.action.Confirm.Message != "" {
+		message = c.action.Confirm.Message
+	}
+
+	title := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("9")).Render(ActionTitle(c.action))
+
+	buttonStyle := lipgloss.NewStyle().Padding(0, 2).MarginLeft(1)
+	confirmButton := buttonStyle.Render("Confirm")
+	cancelButton := buttonStyle.Render("Cancel")
+	if c.confirmed {
+		confirmButton = buttonStyle.Bold(true).Foreground(lipgloss.Color("15")).Background(lipgloss.Color("9")).Render("Confirm")
+	} else {
+		cancelButton = buttonStyle.Bold(true).Reverse(true).Render("Cancel")
+	}
+
+	width := min(max(lipgloss.Width(message), 30), max(c.width-6, 1))
+	dialog := lipgloss.JoinVertical(
+		lipgloss.Center,
+		title,
+		"",
+		lipgloss.NewStyle().Width(width).Align(lipgloss.Center).Render(message),
+		"",
+		lipgloss.JoinHorizontal(lipgloss.Top, cancelButton, confirmButton),
+	)
+	dialog = lipgloss.NewStyle().Border(lipgloss.RoundedBorder(), true).BorderForeground(lipgloss.Color("9")).Padding(1, 2).Render(dialog)
+
+	help := lipgloss.NewStyle().Faint(true).Render(fmt.Sprintf("%s · %s", "y confirm", "n cancel"))
+	return lipgloss.Place(c.width, c.height, lipgloss.Center, lipgloss.Center, lipgloss.JoinVertical(lipgloss.Center, dialog, "", help))
+}
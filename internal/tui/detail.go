@@ -31,6 +31,10 @@ type Detail struct {
 
 	Style    lipgloss.Style
 	Markdown bool
+	Metadata []sunbeam.MetadataItem
+
+	// set for error pages
+	err error
 }
 
 func AnsiStyle() ansi.StyleConfig {
@@ -97,6 +101,10 @@ func (d *Detail) Blur() tea.Cmd {
 
 type DetailMsg string
 
+func (d *Detail) SetStatusInfo(info string) {
+	d.statusBar.SetInfo(info)
+}
+
 func (d *Detail) SetIsLoading(isLoading bool) tea.Cmd {
 	d.isLoading = isLoading
 	if isLoading {
@@ -162,11 +170,18 @@ func (c *Detail) Update(msg tea.Msg) (Page, tea.Cmd) {
 }
 
 func (c *Detail) RefreshContent() error {
+	// the metadata is displayed beside the body on wide screens
+	beside := len(c.Metadata) > 0 && c.width >= 100
+	bodyWidth := c.width
+	if beside {
+		bodyWidth = c.width - c.width/3
+	}
+
 	var content string
 	if c.Markdown {
 		render, err := glamour.NewTermRenderer(
 			glamour.WithStyles(AnsiStyle()),
-			glamour.WithWordWrap(c.width),
+			glamour.WithWordWrap(bodyWidth),
 		)
 		if err != nil {
 			return err
@@ -177,10 +192,16 @@ func (c *Detail) RefreshContent() error {
 			return err
 		}
 	} else {
-		content = wrap.String(wordwrap.String(utils.StripAnsi(c.text), c.width-4), c.width-4)
+		content = wrap.String(wordwrap.String(utils.StripAnsi(c.text), bodyWidth-4), bodyWidth-4)
 		content = lipgloss.NewStyle().Padding(0, 2).Render(content)
 	}
 
+	if beside {
+		content = joinMetadataBeside(content, c.Metadata, c.width)
+	} else {
+		content = joinMetadata(content, c.Metadata, c.width)
+	}
+
 	c.viewport.SetContent(content)
 	return nil
 }
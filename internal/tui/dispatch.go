@@ -0,0 +1,536 @@
+package tui
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/atotto/clipboard"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/pomdtr/sunbeam/internal/config"
+	"github.com/pomdtr/sunbeam/internal/extensions"
+	"github.com/pomdtr/sunbeam/internal/utils"
+	"github.com/pomdtr/sunbeam/pkg/sunbeam"
+)
+
+// actionHost is implemented by the pages running actions. The dispatcher
+// handles the actions, and delegates the page specific behaviors to the host.
+type actionHost interface {
+	showForm(form *Form) tea.Cmd
+	showError(err error) tea.Cmd
+	reload(params map[string]any, step *sequenceStep) tea.Cmd
+	refocus() tea.Msg
+}
+
+// closeFormMsg is sent once a form which does not dispatch an action is submitted.
+type closeFormMsg struct{}
+
+// actionContext describes where an action is run from. Run actions without
+// an extension alias target the current extension.
+type actionContext struct {
+	extension extensions.Extension
+	// the resolved preferences of the extension, see ResolvePreferences
+	preferences map[string]any
+	config      func() (config.Config, error)
+	query       string
+	width       int
+	height      int
+	// in insert mode, copied text is printed for the shell widget to insert it
+	insert bool
+	// set when the action is run as a step of a sequence
+	step *sequenceStep
+}
+
+// done reports the completion of the action to the sequence it is a step of,
+// once msg is handled. Errors, including error pages, fail the step.
+func (ctx actionContext) done(msg tea.Msg) tea.Msg {
+	if ctx.step == nil {
+		return msg
+	}
+
+	if err := stepError(msg); err != nil {
+		return sequenceStepDoneMsg{step: ctx.step, err: err}
+	}
+
+	// the step completes once the page is reloaded
+	if _, ok := msg.(ReloadMsg); ok {
+		return stepActionMsg{
+			action: sunbeam.Action{Type: sunbeam.ActionTypeReload, Reload: &sunbeam.ReloadAction{}},
+			step:   ctx.step,
+		}
+	}
+
+	done := stepDoneCmd(ctx.step, nil)
+	if msg == nil {
+		return done()
+	}
+
+	return tea.Sequence(func() tea.Msg { return msg }, done)()
+}
+
+// fail shows the error, or fails the step the action is run as.
+func (ctx actionContext) fail(host actionHost, err error) tea.Cmd {
+	if ctx.step != nil {
+		return stepDoneCmd(ctx.step, err)
+	}
+
+	return host.showError(err)
+}
+
+func loadConfig() (config.Config, error) {
+	return config.Load(config.Path, config.Layers...)
+}
+
+func dispatchAction(host actionHost, ctx actionContext, action sunbeam.Action) tea.Cmd {
+	switch action.Type {
+	case sunbeam.ActionTypeRun:
+		return runCommand(host, ctx, action)
+	case sunbeam.ActionTypeExec:
+		return execCommand(host, ctx, action)
+	case sunbeam.ActionTypeConfig:
+		return configureExtension(host, ctx, action)
+	case sunbeam.ActionTypeCopy:
+		if ctx.insert {
+			return func() tea.Msg {
+				return ctx.done(PrintMsg{Text: action.Copy.Text})
+			}
+		}
+
+		return func() tea.Msg {
+			if err := clipboard.WriteAll(action.Copy.Text); err != nil {
+				return ctx.done(err)
+			}
+
+			if action.Copy.Exit {
+				return ExitMsg{}
+			}
+
+			return ctx.done(ShowNotificationMsg{"Copied!"})
+		}
+	case sunbeam.ActionTypeEdit:
+		editCmd := exec.Command("sunbeam", "edit", action.Edit.Path)
+		return tea.ExecProcess(editCmd, func(err error) tea.Msg {
+			if err != nil {
+				return ctx.done(err)
+			}
+
+			if action.Edit.Reload {
+				return ctx.done(ReloadMsg{})
+			}
+
+			if action.Edit.Exit {
+				return ExitMsg{}
+			}
+
+			return ctx.done(host.refocus())
+		})
+	case sunbeam.ActionTypeOpen:
+		return func() tea.Msg {
+			if action.Open.Url != "" {
+				if err := utils.Open(action.Open.Url); err != nil {
+					return ctx.done(err)
+				}
+
+				return ExitMsg{}
+			} else if action.Open.Path != "" {
+				if err := utils.Open(fmt.Sprintf("file://%s", action.Open.Path)); err != nil {
+					return ctx.done(err)
+				}
+
+				return ExitMsg{}
+			} else {
+				return ctx.done(fmt.Errorf("invalid target"))
+			}
+		}
+	case sunbeam.ActionTypeExit:
+		return ExitCmd
+	case sunbeam.ActionTypePush:
+		page, err := NewPushedPage(action.Push)
+		if err != nil {
+			return func() tea.Msg { return ctx.done(PushPageMsg{NewErrorPage(err)}) }
+		}
+
+		runner := NewStaticRunner(ctx.extension, sunbeam.Payload{Preferences: ctx.preferences}, page)
+		runner.SetInsert(ctx.insert)
+		return func() tea.Msg { return ctx.done(PushPageMsg{runner}) }
+	case sunbeam.ActionTypeSequence:
+		return startSequence(action, ctx.step)
+	case sunbeam.ActionTypePrint:
+		return func() tea.Msg {
+			return ctx.done(PrintMsg{Text: action.Print.Text})
+		}
+	case sunbeam.ActionTypeReload:
+		var params map[string]any
+		if action.Reload != nil {
+			params = action.Reload.Params
+		}
+
+		return host.reload(params, ctx.step)
+	}
+
+	return nil
+}
+
+// resolveExtension returns the extension targeted by the alias, and its
+// preferences. If some required preferences are missing, a form is returned
+// instead, which saves the preferences and dispatches the action again.
+func resolveExtension(ctx actionContext, alias string, action sunbeam.Action) (extensions.Extension, map[string]any, *Form, error) {
+	if alias == "" {
+		return ctx.extension, ctx.preferences, nil, nil
+	}
+
+	cfg, err := ctx.config()
+	if err != nil {
+		return extensions.Extension{}, nil, nil, err
+	}
+
+	extensionConfig, ok := cfg.Extensions[alias]
+	if !ok {
+		return extensions.Extension{}, nil, nil, fmt.Errorf("extension %s not found", alias)
+	}
+
+	extension, err := extensions.LoadExtension(extensionConfig.Origin)
+	if err != nil {
+		return extensions.Extension{}, nil, nil, fmt.Errorf("failed to load extension %s: %w", alias, err)
+	}
+
+	preferences := make(map[string]any)
+	for name, value := range extensionConfig.Preferences {
+		preferences[name] = value
+	}
+
+	envs, err := ExtractPreferencesFromEnv(alias, extension)
+	if err != nil {
+		return extensions.Extension{}, nil, nil, err
+	}
+	for name, value := range envs {
+		preferences[name] = value
+	}
+
+	preferences, err = extension.ResolvePreferences(preferences)
+	if err != nil {
+		return extensions.Extension{}, nil, nil, err
+	}
+
+	missing := FindMissingPreferences(extension.Manifest.Preferences, preferences)
+	for _, preference := range missing {
+		if preference.Optional {
+			continue
+		}
+
+		form := NewForm(func(values map[string]any) tea.Msg {
+			if extensionConfig.Preferences == nil {
+				extensionConfig.Preferences = make(map[string]any)
+			}
+
+			values, err := extension.SaveSecrets(values)
+			if err != nil {
+				return ctx.done(err)
+			}
+
+			for k, v := range values {
+				extensionConfig.Preferences[k] = v
+			}
+
+			cfg.Extensions[alias] = extensionConfig
+			if !cfg.Loaded() {
+				return actionMsg(action, ctx.step)
+			}
+
+			if err := cfg.Save(); err != nil {
+				return ctx.done(err)
+			}
+
+			return actionMsg(action, ctx.step)
+		}, missing...)
+		form.SetOptionsLoader(func(input sunbeam.Input) ([]sunbeam.InputOption, error) {
+			return extension.Options(input, preferences)
+		})
+
+		return extension, preferences, form, nil
+	}
+
+	return extension, preferences, nil, nil
+}
+
+func runCommand(host actionHost, ctx actionContext, action sunbeam.Action) tea.Cmd {
+	extension, preferences, form, err := resolveExtension(ctx, action.Run.Extension, action)
+	if err != nil {
+		return ctx.fail(host, err)
+	}
+
+	if form != nil {
+		form.SetSize(ctx.width, ctx.height)
+		return host.showForm(form)
+	}
+
+	command, ok := extension.Command(action.Run.Command)
+	if !ok {
+		return ctx.fail(host, fmt.Errorf("command %s not found", action.Run.Command))
+	}
+
+	missing := FindMissingInputs(command.Params, action.Run.Params)
+	for _, param := range missing {
+		if param.Optional {
+			continue
+		}
+
+		form := NewForm(func(values map[string]any) tea.Msg {
+			params := make(map[string]any)
+			for k, v := range action.Run.Params {
+				params[k] = v
+			}
+
+			for k, v := range values {
+				params[k] = v
+			}
+
+			props := *action.Run
+			props.Params = params
+
+			submitted := action
+			submitted.Run = &props
+			return actionMsg(submitted, ctx.step)
+		}, missing...)
+		form.SetOptionsLoader(func(input sunbeam.Input) ([]sunbeam.InputOption, error) {
+			return extension.Options(input, preferences)
+		})
+
+		form.SetSize(ctx.width, ctx.height)
+		return host.showForm(form)
+	}
+
+	input := sunbeam.Payload{
+		Command:     command.Name,
+		Params:      make(map[string]any),
+		Preferences: preferences,
+	}
+
+	for k, v := range action.Run.Params {
+		input.Params[k] = v
+	}
+
+	switch command.Mode {
+	case sunbeam.CommandModeSearch, sunbeam.CommandModeFilter, sunbeam.CommandModeDetail:
+		runner := NewRunner(extension, input)
+		runner.SetInsert(ctx.insert)
+		return func() tea.Msg { return ctx.done(PushPageMsg{runner}) }
+	case sunbeam.CommandModeSilent:
+		return func() tea.Msg {
+			output, err := extension.Output(input)
+			if err != nil {
+				return ctx.done(PushPageMsg{NewErrorPage(err)})
+			}
+
+			if action.Run.Reload {
+				return ctx.done(ReloadMsg{})
+			}
+
+			if action.Run.Exit {
+				return ExitMsg{}
+			}
+
+			if len(output) > 0 {
+				output = bytes.Trim(output, "\n")
+				rows := strings.Split(string(output), "\n")
+				return ctx.done(ShowNotificationMsg{rows[len(rows)-1]})
+			}
+
+			return ctx.done(nil)
+		}
+	case sunbeam.CommandModeTTY:
+		timeout := extension.Timeout(command.Name)
+		cmdCtx, cancel := extensions.WithTimeout(context.Background(), timeout)
+		cmd, err := extension.InteractiveCmdContext(cmdCtx, input)
+		if err != nil {
+			cancel()
+			return ctx.fail(host, err)
+		}
+
+		start := time.Now()
+		return tea.ExecProcess(cmd, func(err error) tea.Msg {
+			defer cancel()
+			if err != nil && errors.Is(cmdCtx.Err(), context.DeadlineExceeded) {
+				err = extensions.TimeoutError{Timeout: timeout}
+			}
+			extension.Log(input, start, "", err)
+
+			if err != nil {
+				return ctx.done(PushPageMsg{NewErrorPage(err)})
+			}
+
+			if action.Run.Reload {
+				return ctx.done(ReloadMsg{})
+			}
+
+			if action.Run.Exit {
+				return ExitMsg{}
+			}
+
+			return ctx.done(host.refocus())
+		})
+	}
+
+	return ctx.fail(host, fmt.Errorf("invalid command mode: %s", command.Mode))
+}
+
+func execCommand(host actionHost, ctx actionContext, action sunbeam.Action) tea.Cmd {
+	cmd := exec.Command("sh", "-c", action.Exec.Command)
+	cmd.Env = os.Environ()
+	for key, value := range action.Exec.Env {
+		cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%s", key, expandEnv(ctx, value)))
+	}
+
+	if action.Exec.Stdin != "" {
+		cmd.Stdin = strings.NewReader(action.Exec.Stdin)
+	}
+
+	cmd.Dir = action.Exec.Dir
+	if strings.HasPrefix(cmd.Dir, "~") {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return ctx.fail(host, err)
+		}
+
+		cmd.Dir = filepath.Join(homeDir, strings.TrimPrefix(cmd.Dir, "~"))
+	}
+
+	if !filepath.IsAbs(cmd.Dir) {
+		wd, err := os.Getwd()
+		if err != nil {
+			return ctx.fail(host, err)
+		}
+
+		cmd.Dir = filepath.Join(wd, cmd.Dir)
+	}
+
+	if !action.Exec.Interactive {
+		return func() tea.Msg {
+			output, err := cmd.Output()
+			if err != nil {
+				return ctx.done(err)
+			}
+
+			if action.Exec.Exit {
+				return ExitMsg{}
+			}
+
+			if len(output) > 0 {
+				output = bytes.Trim(output, "\n")
+				rows := strings.Split(string(output), "\n")
+				return ctx.done(ShowNotificationMsg{rows[len(rows)-1]})
+			}
+
+			return ctx.done(nil)
+		}
+	}
+
+	return tea.ExecProcess(cmd, func(err error) tea.Msg {
+		if err != nil {
+			return ctx.done(err)
+		}
+
+		if action.Exec.Exit {
+			return ExitMsg{}
+		}
+
+		return ctx.done(host.refocus())
+	})
+}
+
+// expandEnv replaces the references to the preferences and the current query
+// in value. Other references are resolved from the environment.
+func expandEnv(ctx actionContext, value string) string {
+	return os.Expand(value, func(name string) string {
+		if name == "query" {
+			return ctx.query
+		}
+
+		if preference, ok := ctx.preferences[name]; ok && preference != nil {
+			return fmt.Sprint(preference)
+		}
+
+		return os.Getenv(name)
+	})
+}
+
+func configureExtension(host actionHost, ctx actionContext, action sunbeam.Action) tea.Cmd {
+	alias := action.Config.Extension
+	cfg, err := ctx.config()
+	if err != nil {
+		return ctx.fail(host, err)
+	}
+
+	extensionConfig, ok := cfg.Extensions[alias]
+	if !ok {
+		return ctx.fail(host, fmt.Errorf("extension %s not found", alias))
+	}
+
+	extension, err := extensions.LoadExtension(extensionConfig.Origin)
+	if err != nil {
+		return ctx.fail(host, fmt.Errorf("failed to load extension %s: %w", alias, err))
+	}
+
+	preferences := make(map[string]any)
+	for name, value := range extensionConfig.Preferences {
+		preferences[name] = value
+	}
+
+	if err := extension.LoadSecrets(preferences); err != nil {
+		return ctx.fail(host, err)
+	}
+
+	inputs := make([]sunbeam.Input, 0)
+	for _, input := range extension.Manifest.Preferences {
+		// references are edited in the config file
+		if extensions.IsPreferenceReference(preferences[input.Name]) {
+			continue
+		}
+
+		if preference := preferences[input.Name]; preference != nil {
+			input.Default = preference
+		}
+		input.Optional = false
+		inputs = append(inputs, input)
+	}
+
+	if len(inputs) == 0 {
+		return ctx.fail(host, fmt.Errorf("the preferences of %s are references, edit them in the config file", alias))
+	}
+
+	form := NewForm(func(values map[string]any) tea.Msg {
+		values, err := extension.SaveSecrets(values)
+		if err != nil {
+			return ctx.done(err)
+		}
+
+		for name, value := range extensionConfig.Preferences {
+			if extensions.IsPreferenceReference(value) {
+				values[name] = value
+			}
+		}
+		extensionConfig.Preferences = values
+		cfg.Extensions[alias] = extensionConfig
+		if !cfg.Loaded() {
+			return ctx.done(closeFormMsg{})
+		}
+
+		if err := cfg.Save(); err != nil {
+			return ctx.done(err)
+		}
+
+		return ctx.done(closeFormMsg{})
+	}, inputs...)
+	form.SetOptionsLoader(func(input sunbeam.Input) ([]sunbeam.InputOption, error) {
+		return extension.Options(input, extensionConfig.Preferences)
+	})
+	form.SetSize(ctx.width, ctx.height)
+
+	return host.showForm(form)
+}
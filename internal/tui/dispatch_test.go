@@ -0,0 +1,89 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/pomdtr/sunbeam/internal/config"
+	"github.com/pomdtr/sunbeam/pkg/sunbeam"
+)
+
+func TestExecActionsUseResolvedPreferences(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(dir, "cache"))
+	t.Setenv("XDG_DATA_HOME", filepath.Join(dir, "data"))
+	t.Setenv("SUNBEAM_SECRETS_KEY_FILE", filepath.Join(dir, "secrets.key"))
+	if err := os.WriteFile(filepath.Join(dir, "secrets.key"), []byte(strings.Repeat("ab", 32)), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	entrypoint := filepath.Join(dir, "extension.sh")
+	manifest := `{"title": "Test", "preferences": [{"name": "token", "title": "Token", "type": "string"}, {"name": "password", "title": "Password", "type": "secret"}], "commands": [{"name": "run", "title": "Run", "mode": "silent"}]}`
+	if err := os.WriteFile(entrypoint, []byte("#!/bin/sh\necho '"+manifest+"'\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("SUNBEAM_TEST_TOKEN", "s3cr3t")
+	ctx := actionContext{
+		config: func() (config.Config, error) {
+			return config.Config{Extensions: map[string]config.ExtensionConfig{
+				"test": {
+					Origin:      entrypoint,
+					Preferences: map[string]any{"token": map[string]any{"$env": "SUNBEAM_TEST_TOKEN"}},
+				},
+			}}, nil
+		},
+	}
+
+	extension, _, _, err := resolveExtension(ctx, "test", sunbeam.Action{})
+	if err != nil {
+		t.Fatalf("failed to resolve extension: %v", err)
+	}
+
+	if err := extension.Secrets().Set("password", "hunter2"); err != nil {
+		t.Fatal(err)
+	}
+
+	_, preferences, form, err := resolveExtension(ctx, "test", sunbeam.Action{})
+	if err != nil || form != nil {
+		t.Fatalf("failed to resolve extension: %v", err)
+	}
+
+	ctx.preferences = preferences
+	if value := expandEnv(ctx, "$token:$password"); value != "s3cr3t:hunter2" {
+		t.Errorf("expected the resolved preferences, got %q", value)
+	}
+}
+
+func TestPreferencesOfInMemoryConfigsAreNotSaved(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(dir, "cache"))
+	t.Setenv("XDG_DATA_HOME", filepath.Join(dir, "data"))
+
+	entrypoint := filepath.Join(dir, "extension.sh")
+	manifest := `{"title": "Test", "preferences": [{"name": "token", "title": "Token", "type": "string"}], "commands": [{"name": "run", "title": "Run", "mode": "silent"}]}`
+	if err := os.WriteFile(entrypoint, []byte("#!/bin/sh\necho '"+manifest+"'\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := actionContext{
+		config: func() (config.Config, error) {
+			return config.Config{Extensions: map[string]config.ExtensionConfig{
+				"test": {Origin: entrypoint},
+			}}, nil
+		},
+	}
+
+	action := sunbeam.Action{Type: sunbeam.ActionTypeRun}
+	_, _, form, err := resolveExtension(ctx, "test", action)
+	if err != nil || form == nil {
+		t.Fatalf("expected a preference form, got %v", err)
+	}
+
+	if msg := form.submitMsg(map[string]any{"token": "s3cr3t"}); !reflect.DeepEqual(msg, action) {
+		t.Errorf("expected the action to run without saving the config, got %v", msg)
+	}
+}
@@ -15,6 +15,7 @@ func NewErrorPage(err error, additionalActions ...sunbeam.Action) *Detail {
 	actions = append(actions, additionalActions...)
 
 	detail := NewDetail(err.Error(), actions...)
+	detail.err = err
 
 	return detail
 }
@@ -1,6 +1,7 @@
 package tui
 
 import (
+	"fmt"
 	"sort"
 	"strings"
 
@@ -13,7 +14,10 @@ import (
 type FilterItem interface {
 	FilterValue() string
 	Render(width int, selected bool) string
+	RenderCell(width int, selected bool) string
 	ID() string
+	// Group returns the section of the item, items without a section are not grouped
+	Group() string
 }
 
 type Filter struct {
@@ -27,9 +31,14 @@ type Filter struct {
 	filtered []FilterItem
 
 	DrawLines bool
-	cursor    int
+	// Columns enables the grid layout when greater than zero
+	Columns int
+	cursor  int
 }
 
+// every grid cell has two lines of content, surrounded by a border
+const cellHeight = 4
+
 func NewFilter(items ...FilterItem) Filter {
 	viewport := viewport.New(0, 0)
 	viewport.Style = lipgloss.NewStyle().Padding(0, 1)
@@ -59,7 +68,7 @@ func (f Filter) Selection() FilterItem {
 
 func (f *Filter) SetItems(items ...FilterItem) {
 	f.items = items
-	f.filtered = items
+	f.filtered = groupItems(items)
 
 	if f.cursor < 0 {
 		f.cursor = 0
@@ -70,6 +79,15 @@ func (f *Filter) SetItems(items ...FilterItem) {
 	}
 }
 
+func (f *Filter) AddItems(items ...FilterItem) {
+	f.items = append(f.items, items...)
+	f.filtered = groupItems(f.items)
+
+	if f.cursor < 0 && len(f.filtered) > 0 {
+		f.cursor = 0
+	}
+}
+
 func (f *Filter) FilterItems(query string) {
 	f.Query = query
 	values := make([]string, len(f.items))
@@ -79,7 +97,7 @@ func (f *Filter) FilterItems(query string) {
 	// If the search field is empty, let's not display the matches
 	// (none), but rather display all possible choices.
 	if query == "" {
-		f.filtered = f.items
+		f.filtered = groupItems(f.items)
 	} else {
 		f.filtered = make([]FilterItem, 0)
 		for i := 0; i < len(f.items); i++ {
@@ -93,6 +111,9 @@ func (f *Filter) FilterItems(query string) {
 		sort.SliceStable(f.filtered, func(i, j int) bool {
 			return fzf.Score(f.filtered[i].FilterValue(), query) > fzf.Score(f.filtered[j].FilterValue(), query)
 		})
+
+		// matches are sorted by score inside each section, sections without matches are hidden
+		f.filtered = groupItems(f.filtered)
 	}
 
 	if f.cursor >= len(f.filtered) {
@@ -100,12 +121,37 @@ func (f *Filter) FilterItems(query string) {
 	}
 }
 
+// groupItems keeps the items of a section together. Sections are ordered
+// by their first appearance.
+func groupItems(items []FilterItem) []FilterItem {
+	ranks := make(map[string]int)
+	for _, item := range items {
+		if _, ok := ranks[item.Group()]; !ok {
+			ranks[item.Group()] = len(ranks)
+		}
+	}
+
+	if len(ranks) < 2 {
+		return items
+	}
+
+	grouped := make([]FilterItem, len(items))
+	copy(grouped, items)
+	sort.SliceStable(grouped, func(i, j int) bool {
+		return ranks[grouped[i].Group()] < ranks[grouped[j].Group()]
+	})
+
+	return grouped
+}
+
 func (f *Filter) Select(id string) {
 	for i, item := range f.filtered {
 		if item.ID() == id {
 			f.cursor = i
 		}
 	}
+
+	f.scrollToCursor()
 }
 
 func (m Filter) Init() tea.Cmd { return nil }
@@ -128,25 +174,24 @@ func (m Filter) View() string {
 		return lipgloss.Place(m.Width, m.Height, lipgloss.Center, lipgloss.Center, emptyText)
 	}
 
-	index := m.minIndex
-	nbVisibleItems := m.Height
-	if m.DrawLines {
-		nbVisibleItems = nbVisibleItems/2 + nbVisibleItems%2
+	if m.Columns > 0 {
+		return m.gridView()
 	}
 
-	for nbVisibleItems > 0 && index < len(m.filtered) {
+	end := m.minIndex + m.visibleItems(m.minIndex)
+	for index := m.minIndex; index < end; index++ {
 		item := m.filtered[index]
-		itemView := item.Render(itemWidth, index == m.cursor)
-		rows = append(rows, itemView)
-
-		index++
-		nbVisibleItems--
-
-		if m.DrawLines && index < len(m.filtered) && nbVisibleItems > 0 {
+		if m.startsSection(index, m.minIndex) {
+			header := lipgloss.NewStyle().Bold(true).Faint(true).Render(fmt.Sprintf("  %s", item.Group()))
+			rows = append(rows, header)
+		} else if m.DrawLines && index > m.minIndex {
 			separator := strings.Repeat("─", itemWidth)
 			separator = lipgloss.NewStyle().Faint(true).Render(separator)
 			rows = append(rows, separator)
 		}
+
+		itemView := item.Render(itemWidth, index == m.cursor)
+		rows = append(rows, itemView)
 	}
 
 	if len(rows) == 0 {
@@ -158,7 +203,33 @@ func (m Filter) View() string {
 	return lipgloss.Place(m.Width, m.Height, lipgloss.Left, lipgloss.Top, filteredView)
 }
 
+func (m Filter) gridView() string {
+	cellWidth := (m.Width - 2) / m.Columns
+
+	rows := make([]string, 0)
+	for index := m.minIndex; index < len(m.filtered) && len(rows) < m.visibleRows(); index += m.Columns {
+		cells := make([]string, 0, m.Columns)
+		for i := index; i < min(index+m.Columns, len(m.filtered)); i++ {
+			cells = append(cells, m.filtered[i].RenderCell(cellWidth, i == m.cursor))
+		}
+
+		rows = append(rows, lipgloss.JoinHorizontal(lipgloss.Top, cells...))
+	}
+
+	gridView := lipgloss.JoinVertical(lipgloss.Left, rows...)
+	gridView = lipgloss.NewStyle().Padding(0, 1).Render(gridView)
+	return lipgloss.Place(m.Width, m.Height, lipgloss.Left, lipgloss.Top, gridView)
+}
+
+func (m Filter) visibleRows() int {
+	return max(1, m.Height/cellHeight)
+}
+
 func (f Filter) Update(msg tea.Msg) (Filter, tea.Cmd) {
+	if f.Columns > 0 {
+		return f.updateGrid(msg)
+	}
+
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		switch msg.String() {
@@ -182,6 +253,129 @@ func (f Filter) Update(msg tea.Msg) (Filter, tea.Cmd) {
 	return f, nil
 }
 
+// updateGrid moves the cursor in both axes: left and right move between
+// cells, up and down move between rows.
+func (f Filter) updateGrid(msg tea.Msg) (Filter, tea.Cmd) {
+	if len(f.filtered) == 0 {
+		return f, nil
+	}
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "right", "ctrl+n":
+			f.cursor = (f.cursor + 1) % len(f.filtered)
+		case "left", "ctrl+p":
+			f.cursor = (f.cursor - 1 + len(f.filtered)) % len(f.filtered)
+		case "down", "ctrl+j":
+			f.moveRows(1, true)
+		case "up", "ctrl+k":
+			f.moveRows(-1, true)
+		case "ctrl+d":
+			f.moveRows(f.visibleRows(), false)
+		case "ctrl+u":
+			f.moveRows(-f.visibleRows(), false)
+		default:
+			return f, nil
+		}
+
+		f.scrollToCursor()
+	}
+
+	return f, nil
+}
+
+// moveRows moves the cursor by n rows, keeping the column when possible.
+// If wrap is set, moving past the first or last row wraps around.
+func (f *Filter) moveRows(n int, wrap bool) {
+	lastRow := (len(f.filtered) - 1) / f.Columns
+	row, column := f.cursor/f.Columns, f.cursor%f.Columns
+
+	switch {
+	case row+n > lastRow && row == lastRow && wrap:
+		row = 0
+	case row+n > lastRow:
+		row = lastRow
+	case row+n < 0 && row == 0 && wrap:
+		row = lastRow
+	case row+n < 0:
+		row = 0
+	default:
+		row += n
+	}
+
+	f.cursor = min(row*f.Columns+column, len(f.filtered)-1)
+}
+
+// NearEnd reports whether the cursor is within a screen of the last item.
+func (m Filter) NearEnd() bool {
+	if len(m.filtered) == 0 {
+		return false
+	}
+
+	if m.Columns > 0 {
+		return m.cursor >= len(m.filtered)-m.Columns*m.visibleRows()
+	}
+
+	return m.cursor >= len(m.filtered)-m.nbVisibleItems()
+}
+
+// startsSection reports whether a section header must be rendered above the
+// item. The header of the first visible item is always rendered.
+func (m Filter) startsSection(index int, minIndex int) bool {
+	section := m.filtered[index].Group()
+	if section == "" {
+		return false
+	}
+
+	return index == minIndex || m.filtered[index-1].Group() != section
+}
+
+// visibleItems returns the number of items that fit in the view when the
+// first visible item is at minIndex, taking the section headers into account.
+func (m Filter) visibleItems(minIndex int) int {
+	var count, height int
+	for index := minIndex; index < len(m.filtered); index++ {
+		lines := 1
+		if m.startsSection(index, minIndex) {
+			lines++
+		} else if m.DrawLines && index > minIndex {
+			lines++
+		}
+
+		if height+lines > m.Height {
+			break
+		}
+
+		height += lines
+		count++
+	}
+
+	return count
+}
+
+func (m *Filter) scrollToCursor() {
+	if m.Columns > 0 {
+		row, minRow := max(0, m.cursor)/m.Columns, m.minIndex/m.Columns
+		if row < minRow {
+			minRow = row
+		} else if row >= minRow+m.visibleRows() {
+			minRow = row - m.visibleRows() + 1
+		}
+
+		m.minIndex = minRow * m.Columns
+		return
+	}
+
+	if m.cursor < m.minIndex {
+		m.minIndex = max(0, m.cursor)
+	}
+
+	for m.minIndex < m.cursor && m.cursor >= m.minIndex+m.visibleItems(m.minIndex) {
+		m.minIndex++
+	}
+}
+
 func (m Filter) itemHeight() int {
 	if m.DrawLines {
 		return 2
@@ -192,13 +386,11 @@ func (m Filter) itemHeight() int {
 func (m *Filter) CursorUp() {
 	if m.cursor > 0 {
 		m.cursor = m.cursor - 1
-		if m.cursor < m.minIndex {
-			m.minIndex = m.cursor
-		}
 	} else {
 		m.cursor = len(m.filtered) - 1
-		m.minIndex = max(0, m.cursor-m.nbVisibleItems()+1)
 	}
+
+	m.scrollToCursor()
 }
 
 func (m Filter) nbVisibleItems() int {
@@ -208,11 +400,9 @@ func (m Filter) nbVisibleItems() int {
 func (m *Filter) CursorDown() {
 	if m.cursor < len(m.filtered)-1 {
 		m.cursor += 1
-		if m.cursor >= m.minIndex+m.nbVisibleItems() {
-			m.minIndex += 1
-		}
 	} else {
 		m.cursor = 0
-		m.minIndex = 0
 	}
+
+	m.scrollToCursor()
 }
@@ -26,6 +26,8 @@ type Form struct {
 	focusIndex   int
 
 	inputs []Input
+	params []sunbeam.Input
+	errors []string
 }
 
 func ExtractPreferencesFromEnv(alias string, extension extensions.Extension) (map[string]any, error) {
@@ -35,7 +37,7 @@ func ExtractPreferencesFromEnv(alias string, extension extensions.Extension) (ma
 		env = strings.ReplaceAll(env, "-", "_")
 		if value, ok := os.LookupEnv(env); ok {
 			switch input.Type {
-			case sunbeam.InputString:
+			case sunbeam.InputString, sunbeam.InputSelect, sunbeam.InputSecret:
 				preferences[input.Name] = value
 			case sunbeam.InputBoolean:
 				value, err := strconv.ParseBool(value)
@@ -91,21 +93,32 @@ func NewForm(submitMsg func(map[string]any) tea.Msg, params ...sunbeam.Input) *F
 	viewport := viewport.New(0, 0)
 
 	var inputs []Input
+	var specs []sunbeam.Input
 	for _, param := range params {
 		switch param.Type {
 		case sunbeam.InputString:
 			inputs = append(inputs, NewTextField(param, false))
+		case sunbeam.InputSecret:
+			inputs = append(inputs, NewTextField(param, true))
 		case sunbeam.InputBoolean:
 			inputs = append(inputs, NewCheckbox(param))
 		case sunbeam.InputNumber:
 			inputs = append(inputs, NewNumberField(param))
+		case sunbeam.InputSelect:
+			inputs = append(inputs, NewSelectField(param))
+		default:
+			continue
 		}
+
+		specs = append(specs, param)
 	}
 
 	form := &Form{
 		submitMsg: submitMsg,
 		viewport:  viewport,
 		inputs:    inputs,
+		params:    specs,
+		errors:    make([]string, len(inputs)),
 	}
 
 	return form
@@ -120,8 +133,25 @@ func (c *Form) SetIsLoading(isLoading bool) tea.Cmd {
 	return nil
 }
 
+// SetOptionsLoader sets the function used to fetch the options of the select
+// fields that have an options command.
+func (c *Form) SetOptionsLoader(loader func(sunbeam.Input) ([]sunbeam.InputOption, error)) {
+	for _, input := range c.inputs {
+		if field, ok := input.(*SelectField); ok {
+			field.loader = loader
+		}
+	}
+}
+
 func (c Form) Init() tea.Cmd {
-	return c.Focus()
+	cmds := []tea.Cmd{c.Focus()}
+	for _, input := range c.inputs {
+		if field, ok := input.(*SelectField); ok {
+			cmds = append(cmds, field.Load())
+		}
+	}
+
+	return tea.Batch(cmds...)
 }
 
 func (c Form) Focus() tea.Cmd {
@@ -145,22 +175,31 @@ func (c *Form) CurrentItem() Input {
 
 func (f Form) itemsHeight() int {
 	height := 0
-	for _, item := range f.inputs {
-		height += item.Height() + 2
+	for i := range f.inputs {
+		height += f.inputHeight(i)
 	}
 	return height
 }
 
+func (f Form) inputHeight(i int) int {
+	height := f.inputs[i].Height() + 2
+	if f.errors[i] != "" {
+		height++
+	}
+
+	return height
+}
+
 func (c *Form) ScrollViewport() {
 	cursorOffset := 0
 	for i := 0; i < c.focusIndex; i++ {
-		cursorOffset += c.inputs[i].Height() + 2
+		cursorOffset += c.inputHeight(i)
 	}
 
 	if c.CurrentItem() == nil {
 		return
 	}
-	maxRequiredVisibleHeight := cursorOffset + c.CurrentItem().Height() + 2
+	maxRequiredVisibleHeight := cursorOffset + c.inputHeight(c.focusIndex)
 	for maxRequiredVisibleHeight > c.viewport.Height+c.scrollOffset {
 		c.viewport.LineDown(1)
 		c.scrollOffset += 1
@@ -197,31 +236,39 @@ func (c Form) Update(msg tea.Msg) (Page, tea.Cmd) {
 				c.focusIndex = len(c.inputs) - 1
 			}
 
-			cmds := make([]tea.Cmd, len(c.inputs))
-			for i := 0; i <= len(c.inputs)-1; i++ {
-				if i == c.focusIndex {
-					// Set focused state
-					cmds[i] = c.inputs[i].Focus()
-					continue
+			return &c, c.focusInput(c.focusIndex)
+		case "alt+enter":
+			values := make(map[string]any)
+			invalidIndex := -1
+			for i, input := range c.inputs {
+				// nil values are omitted, so that the default of the input applies
+				value := input.Value()
+				if value != nil {
+					values[input.Name()] = value
+				}
+
+				c.errors[i] = ""
+				if err := c.validate(i, value); err != nil {
+					c.errors[i] = err.Error()
+					if invalidIndex == -1 {
+						invalidIndex = i
+					}
 				}
-				// Remove focused state
-				c.inputs[i].Blur()
 			}
 
-			c.renderInputs()
-			if c.viewport.Height > 0 {
-				c.ScrollViewport()
+			// keep the form open, and focus the first invalid input
+			if invalidIndex != -1 {
+				return &c, c.focusInput(invalidIndex)
 			}
 
-			return &c, tea.Batch(cmds...)
-		case "alt+enter":
 			return &c, func() tea.Msg {
-				values := make(map[string]any)
-				for _, input := range c.inputs {
-					values[input.Name()] = input.Value()
-				}
 				return c.submitMsg(values)
 			}
+		default:
+			// the error is cleared as soon as the user edits the input
+			if c.focusIndex < len(c.errors) {
+				c.errors[c.focusIndex] = ""
+			}
 		}
 	}
 
@@ -236,6 +283,42 @@ func (c Form) Update(msg tea.Msg) (Page, tea.Cmd) {
 	return &c, tea.Batch(cmds...)
 }
 
+func (c *Form) focusInput(index int) tea.Cmd {
+	c.focusIndex = index
+
+	cmds := make([]tea.Cmd, len(c.inputs))
+	for i := 0; i <= len(c.inputs)-1; i++ {
+		if i == c.focusIndex {
+			// Set focused state
+			cmds[i] = c.inputs[i].Focus()
+			continue
+		}
+		// Remove focused state
+		c.inputs[i].Blur()
+	}
+
+	c.renderInputs()
+	if c.viewport.Height > 0 {
+		c.ScrollViewport()
+	}
+
+	return tea.Batch(cmds...)
+}
+
+func (c Form) validate(index int, value any) error {
+	param := c.params[index]
+	if value == nil || value == "" {
+		if !param.Optional {
+			return fmt.Errorf("%s is required", param.Title)
+		}
+
+		// optional inputs can be left blank, whatever their constraints
+		return nil
+	}
+
+	return param.Validate(value)
+}
+
 func (c *Form) renderInputs() {
 	selectedBorder := lipgloss.NewStyle().Border(lipgloss.RoundedBorder(), true).BorderForeground(lipgloss.Color("13"))
 	normalBorder := lipgloss.NewStyle().Border(lipgloss.RoundedBorder(), true)
@@ -251,6 +334,10 @@ func (c *Form) renderInputs() {
 
 		titleView := fmt.Sprintf("%s ", input.Title())
 		itemViews[i] = lipgloss.JoinHorizontal(lipgloss.Center, lipgloss.NewStyle().Bold(true).Render(titleView), inputView)
+		if c.errors[i] != "" {
+			errorView := lipgloss.NewStyle().Foreground(lipgloss.Color("9")).Padding(0, 1).Render(c.errors[i])
+			itemViews[i] = lipgloss.JoinVertical(lipgloss.Right, itemViews[i], errorView)
+		}
 		if lipgloss.Width(itemViews[i]) > maxWidth {
 			maxWidth = lipgloss.Width(itemViews[i])
 		}
@@ -0,0 +1,33 @@
+package tui
+
+import (
+	"testing"
+
+	"github.com/pomdtr/sunbeam/pkg/sunbeam"
+)
+
+func TestFormValidate(t *testing.T) {
+	minLength := 3
+	form := NewForm(nil,
+		sunbeam.Input{Name: "required", Title: "Required", Type: sunbeam.InputString, Pattern: "^[a-z]+$"},
+		sunbeam.Input{Name: "optional", Title: "Optional", Type: sunbeam.InputString, Optional: true, Pattern: "^[a-z]+$", MinLength: &minLength},
+	)
+
+	for _, tc := range []struct {
+		index int
+		value any
+		valid bool
+	}{
+		{0, "", false},
+		{0, "ABC", false},
+		{0, "abc", true},
+		{1, "", true},
+		{1, nil, true},
+		{1, "ab", false},
+		{1, "abc", true},
+	} {
+		if err := form.validate(tc.index, tc.value); (err == nil) != tc.valid {
+			t.Errorf("validate(%s, %q) = %v, expected valid: %v", form.params[tc.index].Name, tc.value, err, tc.valid)
+		}
+	}
+}
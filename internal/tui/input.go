@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"fmt"
 	"os/exec"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -11,6 +12,7 @@ import (
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/pomdtr/sunbeam/internal/fzf"
 	"github.com/pomdtr/sunbeam/pkg/sunbeam"
 )
 
@@ -297,10 +299,17 @@ func NewNumberField(param sunbeam.Input) Input {
 	}
 }
 
+// Value returns nil if the field is empty, and the raw text if it is not a
+// valid number, so that the form can report it.
 func (n NumberField) Value() any {
-	value, err := strconv.Atoi(n.TextField.Value().(string))
+	text := n.TextField.Value().(string)
+	if text == "" {
+		return nil
+	}
+
+	value, err := strconv.Atoi(text)
 	if err != nil {
-		return err
+		return text
 	}
 
 	return value
@@ -319,3 +328,241 @@ func (n NumberField) Update(msg tea.Msg) (Input, tea.Cmd) {
 
 	return n, nil
 }
+
+type SelectField struct {
+	input   sunbeam.Input
+	filter  textinput.Model
+	width   int
+	focused bool
+
+	options  []sunbeam.InputOption
+	filtered []sunbeam.InputOption
+	cursor   int
+	selected *sunbeam.InputOption
+
+	isLoading bool
+	err       error
+	loader    func(sunbeam.Input) ([]sunbeam.InputOption, error)
+}
+
+type SelectOptionsMsg struct {
+	name    string
+	options []sunbeam.InputOption
+	err     error
+}
+
+const maxVisibleOptions = 5
+
+func NewSelectField(input sunbeam.Input) *SelectField {
+	filter := textinput.New()
+	filter.Prompt = ""
+	filter.PlaceholderStyle = lipgloss.NewStyle().Faint(true)
+
+	field := &SelectField{
+		input:  input,
+		filter: filter,
+	}
+
+	field.SetOptions(input.Options...)
+	return field
+}
+
+func (s *SelectField) Name() string {
+	return s.input.Name
+}
+
+func (s *SelectField) Title() string {
+	return s.input.Title
+}
+
+func (s *SelectField) Value() any {
+	if s.selected == nil {
+		return ""
+	}
+
+	return s.selected.Value
+}
+
+// Load fetches the options of the field, if the field has an options command.
+func (s *SelectField) Load() tea.Cmd {
+	if s.loader == nil || s.input.OptionsCommand == "" {
+		return nil
+	}
+
+	s.isLoading = true
+	input := s.input
+	loader := s.loader
+	return func() tea.Msg {
+		options, err := loader(input)
+		return SelectOptionsMsg{name: input.Name, options: options, err: err}
+	}
+}
+
+func (s *SelectField) SetOptions(options ...sunbeam.InputOption) {
+	s.options = options
+
+	var value string
+	if s.selected != nil {
+		value = s.selected.Value
+	} else if defaultValue, ok := s.input.Default.(string); ok {
+		value = defaultValue
+	}
+
+	s.selected = nil
+	for i, option := range options {
+		if option.Value == value {
+			s.selected = &options[i]
+		}
+	}
+
+	s.FilterOptions(s.filter.Value())
+}
+
+func (s *SelectField) FilterOptions(query string) {
+	s.cursor = 0
+	if query == "" {
+		s.filtered = s.options
+		return
+	}
+
+	s.filtered = make([]sunbeam.InputOption, 0)
+	for _, option := range s.options {
+		if fzf.Score(option.Title, query) > 0 {
+			s.filtered = append(s.filtered, option)
+		}
+	}
+
+	sort.SliceStable(s.filtered, func(i, j int) bool {
+		return fzf.Score(s.filtered[i].Title, query) > fzf.Score(s.filtered[j].Title, query)
+	})
+}
+
+func (s *SelectField) Focus() tea.Cmd {
+	s.focused = true
+	s.filter.SetValue("")
+	s.FilterOptions("")
+
+	for i, option := range s.filtered {
+		if s.selected != nil && option.Value == s.selected.Value {
+			s.cursor = i
+		}
+	}
+
+	return s.filter.Focus()
+}
+
+func (s *SelectField) Blur() {
+	s.focused = false
+	s.filter.Blur()
+}
+
+func (s *SelectField) Height() int {
+	if !s.focused {
+		return 1
+	}
+
+	if s.isLoading || s.err != nil || len(s.filtered) == 0 {
+		return 2
+	}
+
+	return 1 + min(len(s.filtered), maxVisibleOptions)
+}
+
+func (s *SelectField) SetWidth(width int) {
+	s.width = width
+	s.filter.Width = width - 1
+}
+
+func (s *SelectField) Update(msg tea.Msg) (Input, tea.Cmd) {
+	switch msg := msg.(type) {
+	case SelectOptionsMsg:
+		if msg.name != s.input.Name {
+			return s, nil
+		}
+
+		s.isLoading = false
+		s.err = msg.err
+		if msg.err == nil {
+			s.SetOptions(msg.options...)
+		}
+
+		return s, nil
+	case tea.KeyMsg:
+		if !s.focused {
+			return s, nil
+		}
+
+		switch msg.String() {
+		case "down", "ctrl+n", "ctrl+j":
+			if len(s.filtered) > 0 {
+				s.cursor = (s.cursor + 1) % len(s.filtered)
+			}
+			return s, nil
+		case "up", "ctrl+p", "ctrl+k":
+			if len(s.filtered) > 0 {
+				s.cursor = (s.cursor - 1 + len(s.filtered)) % len(s.filtered)
+			}
+			return s, nil
+		case "enter":
+			if s.cursor < len(s.filtered) {
+				option := s.filtered[s.cursor]
+				s.selected = &option
+			}
+
+			s.filter.SetValue("")
+			s.FilterOptions("")
+			return s, nil
+		}
+	}
+
+	filter, cmd := s.filter.Update(msg)
+	if filter.Value() != s.filter.Value() {
+		s.FilterOptions(filter.Value())
+	}
+	s.filter = filter
+
+	return s, cmd
+}
+
+func (s *SelectField) View() string {
+	placeholder := s.input.Title
+	if s.selected != nil {
+		placeholder = s.selected.Title
+	}
+
+	if !s.focused {
+		view := placeholder
+		if s.selected == nil {
+			view = lipgloss.NewStyle().Faint(true).Render(view)
+		}
+
+		padding := max(0, s.width-lipgloss.Width(view)-2)
+		return fmt.Sprintf("%s%s ▾", view, strings.Repeat(" ", padding))
+	}
+
+	s.filter.Placeholder = placeholder
+	rows := []string{s.filter.View()}
+	if s.isLoading {
+		rows = append(rows, lipgloss.NewStyle().Faint(true).Render("Loading..."))
+	} else if s.err != nil {
+		rows = append(rows, lipgloss.NewStyle().Foreground(lipgloss.Color("9")).Render(s.err.Error()))
+	} else if len(s.filtered) == 0 {
+		rows = append(rows, lipgloss.NewStyle().Faint(true).Render("No matches"))
+	}
+
+	start := max(0, min(s.cursor-maxVisibleOptions+1, len(s.filtered)-maxVisibleOptions))
+	for i := start; i < len(s.filtered) && i < start+maxVisibleOptions; i++ {
+		title := s.filtered[i].Title
+		if i == s.cursor {
+			rows = append(rows, lipgloss.NewStyle().Foreground(lipgloss.Color("13")).Bold(true).Render(fmt.Sprintf("> %s", title)))
+		} else {
+			rows = append(rows, fmt.Sprintf("  %s", title))
+		}
+	}
+
+	for i := range rows {
+		rows[i] = lipgloss.NewStyle().Width(s.width).MaxWidth(s.width).Render(rows[i])
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Left, rows...)
+}
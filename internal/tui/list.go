@@ -35,6 +35,7 @@ type List struct {
 	Actions       []sunbeam.Action
 	OnQueryChange func(string) tea.Cmd
 	OnSelect      func(string) tea.Cmd
+	OnNextPage    func() tea.Cmd
 }
 
 type ListFocus string
@@ -114,6 +115,8 @@ func (c *List) updateViewport(detail sunbeam.ListItemDetail) {
 		content = lipgloss.NewStyle().Padding(0, 2).Render(content)
 	}
 
+	content = joinMetadata(content, detail.Metadata, c.viewport.Width)
+
 	c.viewport.GotoTop()
 	c.viewport.SetContent(content)
 }
@@ -125,6 +128,22 @@ func (l *List) SetActions(actions ...sunbeam.Action) {
 	}
 }
 
+// SetLayout switches between the list and grid layouts. In grid layout,
+// columns defaults to 5.
+func (l *List) SetLayout(layout sunbeam.ListLayout, columns int) {
+	if layout != sunbeam.ListLayoutGrid {
+		l.filter.Columns = 0
+		return
+	}
+
+	if columns <= 0 {
+		columns = 5
+	}
+
+	l.filter.Columns = columns
+	l.filter.scrollToCursor()
+}
+
 func (l *List) SetEmptyText(text string) {
 	l.filter.EmptyText = text
 }
@@ -174,6 +193,10 @@ func (c *List) SetQuery(query string) tea.Cmd {
 
 func (c *List) FilterItems(query string) {
 	c.filter.FilterItems(query)
+	c.refreshSelection()
+}
+
+func (c *List) refreshSelection() {
 	selection := c.filter.Selection()
 	if selection == nil {
 		c.statusBar.SetActions(c.Actions...)
@@ -239,6 +262,54 @@ func (c *List) SetItems(items ...sunbeam.ListItem) {
 	}
 }
 
+// ReplaceItems replaces the items of the list, keeping the current selection
+// if it is still part of the list.
+func (c *List) ReplaceItems(items ...sunbeam.ListItem) {
+	var selectionID string
+	if selection := c.filter.Selection(); selection != nil {
+		selectionID = selection.ID()
+	}
+
+	c.SetItems(items...)
+	if selectionID != "" {
+		c.filter.Select(selectionID)
+	}
+
+	c.refreshSelection()
+}
+
+// AddItems appends items to the list, keeping the current selection.
+func (c *List) AddItems(items ...sunbeam.ListItem) {
+	filterItems := make([]FilterItem, len(items))
+	for i, item := range items {
+		filterItems[i] = ListItem(item)
+	}
+
+	var selectionID string
+	if selection := c.filter.Selection(); selection != nil {
+		selectionID = selection.ID()
+	}
+
+	c.filter.AddItems(filterItems...)
+	if c.OnQueryChange == nil {
+		c.filter.FilterItems(c.Query())
+	}
+
+	if selectionID != "" {
+		c.filter.Select(selectionID)
+	}
+
+	c.refreshSelection()
+}
+
+func (c List) NearEnd() bool {
+	return c.filter.NearEnd()
+}
+
+func (c *List) SetStatusInfo(info string) {
+	c.statusBar.SetInfo(info)
+}
+
 func (c *List) SetIsLoading(isLoading bool) tea.Cmd {
 	c.isLoading = isLoading
 	if isLoading {
@@ -306,6 +377,11 @@ func (c *List) Update(msg tea.Msg) (Page, tea.Cmd) {
 				return c, cmd
 			}
 
+			// in grid layout, the arrows move the cursor between cells
+			if c.filter.Columns > 0 {
+				break
+			}
+
 			input, cmd := c.input.Update(msg)
 			c.input = input
 			return c, cmd
@@ -360,6 +436,10 @@ func (c *List) Update(msg tea.Msg) (Page, tea.Cmd) {
 	c.filter = filter
 	cmds = append(cmds, cmd)
 
+	if c.OnNextPage != nil && c.NearEnd() {
+		cmds = append(cmds, c.OnNextPage())
+	}
+
 	if c.isLoading {
 		c.spinner, cmd = c.spinner.Update(msg)
 		cmds = append(cmds, cmd)
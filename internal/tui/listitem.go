@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/reflow/truncate"
 	"github.com/pomdtr/sunbeam/pkg/sunbeam"
 )
 
@@ -18,6 +19,10 @@ func (i ListItem) ID() string {
 	return i.Title
 }
 
+func (i ListItem) Group() string {
+	return i.Section
+}
+
 func (i ListItem) FilterValue() string {
 	keywords := []string{i.Title, i.Subtitle}
 	return strings.Trim(strings.Join(keywords, " "), " ")
@@ -70,6 +75,28 @@ func RenderItem(title string, subtitle string, accessories []string, width int,
 
 }
 
+// RenderCell renders the item as a grid cell. The content (or the title if
+// it is missing) is displayed above the title (or the subtitle).
+func (i ListItem) RenderCell(width int, selected bool) string {
+	content, label := i.Content, i.Title
+	if content == "" {
+		content, label = i.Title, i.Subtitle
+	}
+
+	innerWidth := max(0, width-2)
+	content = truncate.String(strings.Split(content, "\n")[0], uint(innerWidth))
+	label = truncate.String(strings.Split(label, "\n")[0], uint(innerWidth))
+
+	cellStyle := lipgloss.NewStyle().Border(lipgloss.RoundedBorder(), true).Width(innerWidth).Align(lipgloss.Center)
+	labelStyle := lipgloss.NewStyle().Faint(true)
+	if selected {
+		cellStyle = cellStyle.BorderForeground(lipgloss.Color("13"))
+		labelStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("13")).Bold(true)
+	}
+
+	return cellStyle.Render(lipgloss.JoinVertical(lipgloss.Center, content, labelStyle.Render(label)))
+}
+
 func (i ListItem) Render(width int, selected bool) string {
 	return RenderItem(i.Title, i.Subtitle, i.Accessories, width, selected)
 }
@@ -0,0 +1,142 @@
+package tui
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/reflow/wordwrap"
+	"github.com/muesli/reflow/wrap"
+	"github.com/pomdtr/sunbeam/pkg/sunbeam"
+)
+
+var tagColors = map[string]lipgloss.Color{
+	"red":     lipgloss.Color("1"),
+	"green":   lipgloss.Color("2"),
+	"yellow":  lipgloss.Color("3"),
+	"blue":    lipgloss.Color("4"),
+	"magenta": lipgloss.Color("5"),
+	"cyan":    lipgloss.Color("6"),
+}
+
+// RenderMetadata renders the metadata items as two aligned columns: the
+// titles on the left, the values on the right.
+func RenderMetadata(items []sunbeam.MetadataItem, width int) string {
+	if len(items) == 0 || width <= 0 {
+		return ""
+	}
+
+	titleWidth := 0
+	for _, item := range items {
+		if item.Type != sunbeam.MetadataItemSeparator {
+			titleWidth = max(titleWidth, lipgloss.Width(item.Title))
+		}
+	}
+	titleWidth = min(titleWidth, width/3)
+	valueWidth := max(1, width-titleWidth-2)
+
+	titleStyle := lipgloss.NewStyle().Faint(true).Width(titleWidth).MarginRight(2)
+	rows := make([]string, 0, len(items))
+	for _, item := range items {
+		var value string
+		switch item.Type {
+		case sunbeam.MetadataItemSeparator:
+			rows = append(rows, lipgloss.NewStyle().Faint(true).Render(strings.Repeat("─", width)))
+			continue
+		case sunbeam.MetadataItemLink:
+			linkStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("4")).Underline(true)
+			if item.Text == "" || item.Text == item.Url {
+				value = linkStyle.Render(wrapText(item.Url, valueWidth))
+				break
+			}
+
+			// the url is shown below the text, so that it can be read and copied
+			value = lipgloss.JoinVertical(
+				lipgloss.Left,
+				linkStyle.Render(wrapText(item.Text, valueWidth)),
+				lipgloss.NewStyle().Faint(true).Render(wrap.String(item.Url, valueWidth)),
+			)
+		case sunbeam.MetadataItemTags:
+			value = renderTags(item.Tags, valueWidth)
+		default:
+			value = wrapText(item.Text, valueWidth)
+		}
+
+		title := titleStyle.Render(wrapText(item.Title, titleWidth))
+		rows = append(rows, lipgloss.JoinHorizontal(lipgloss.Top, title, value))
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Left, rows...)
+}
+
+func renderTags(tags []sunbeam.MetadataTag, width int) string {
+	lines := make([]string, 0)
+	var line string
+	for _, tag := range tags {
+		style := lipgloss.NewStyle().Padding(0, 1).Foreground(lipgloss.Color("0")).Background(lipgloss.Color("7"))
+		if color, ok := tagColors[tag.Color]; ok {
+			style = style.Background(color)
+		} else if strings.HasPrefix(tag.Color, "#") {
+			style = style.Background(lipgloss.Color(tag.Color))
+		}
+
+		view := style.Render(tag.Text)
+		if line != "" && lipgloss.Width(line)+1+lipgloss.Width(view) > width {
+			lines = append(lines, line)
+			line = ""
+		}
+
+		if line == "" {
+			line = view
+		} else {
+			line = line + " " + view
+		}
+	}
+
+	if line != "" {
+		lines = append(lines, line)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func wrapText(text string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+
+	return wrap.String(wordwrap.String(text, width), width)
+}
+
+// joinMetadata renders the metadata below the body, separated by a line.
+func joinMetadata(body string, items []sunbeam.MetadataItem, width int) string {
+	metadata := RenderMetadata(items, width-4)
+	if metadata == "" {
+		return body
+	}
+
+	metadata = lipgloss.NewStyle().Padding(0, 2).Render(metadata)
+	if strings.TrimSpace(body) == "" {
+		return metadata
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Left, strings.TrimRight(body, "\n"), "", metadata)
+}
+
+// joinMetadataBeside renders the metadata on the right of the body, when
+// the width allows it.
+func joinMetadataBeside(body string, items []sunbeam.MetadataItem, width int) string {
+	metadataWidth := width / 3
+	metadata := RenderMetadata(items, metadataWidth-3)
+	if metadata == "" {
+		return body
+	}
+
+	bars := make([]string, max(lipgloss.Height(body), lipgloss.Height(metadata)))
+	for i := range bars {
+		bars[i] = "│"
+	}
+
+	body = lipgloss.NewStyle().Width(width - metadataWidth).Render(body)
+	metadata = lipgloss.NewStyle().Padding(0, 1).Render(metadata)
+	return lipgloss.JoinHorizontal(lipgloss.Top, body, lipgloss.NewStyle().Faint(true).Render(strings.Join(bars, "\n")), metadata)
+}
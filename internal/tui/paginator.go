@@ -1,7 +1,14 @@
 package tui
 
 import (
+	"fmt"
+	"os"
+	"strings"
+
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-isatty"
+	"github.com/muesli/termenv"
 )
 
 func PopPageCmd() tea.Msg {
@@ -40,11 +47,23 @@ func ExitCmd() tea.Msg {
 	return ExitMsg{}
 }
 
+// PrintMsg exits the program, and writes the text to stdout
+type PrintMsg struct {
+	Text string
+}
+
+func PrintCmd(text string) tea.Cmd {
+	return func() tea.Msg {
+		return PrintMsg{Text: text}
+	}
+}
+
 type Paginator struct {
 	width, height int
 
 	pages  []Page
 	hidden bool
+	output string
 }
 
 func NewPaginator(root Page) *Paginator {
@@ -89,6 +108,10 @@ func (m *Paginator) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case ExitMsg:
 		m.hidden = true
 		return m, tea.Quit
+	case PrintMsg:
+		m.output = msg.Text
+		m.hidden = true
+		return m, tea.Quit
 	}
 
 	// Update the current page
@@ -150,10 +173,56 @@ func (m *Paginator) Pop() tea.Cmd {
 	return tea.Sequence(cmds...)
 }
 
+// HasTTY reports whether the TUI can be drawn, either on stdout or on the
+// terminal controlling the process.
+func HasTTY() bool {
+	if isatty.IsTerminal(os.Stdout.Fd()) {
+		return true
+	}
+
+	tty, err := os.Open("/dev/tty")
+	if err != nil {
+		return false
+	}
+	tty.Close()
+
+	return true
+}
+
 func Draw(page Page) error {
+	options := []tea.ProgramOption{tea.WithAltScreen()}
+
+	// stdout is reserved to the output of print actions, ex: $(sunbeam projects)
+	if !isatty.IsTerminal(os.Stdout.Fd()) {
+		tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+		if err != nil {
+			return fmt.Errorf("failed to open terminal: %w", err)
+		}
+		defer tty.Close()
+
+		output := termenv.NewOutput(tty)
+		termenv.SetDefaultOutput(output)
+		lipgloss.SetColorProfile(output.ColorProfile())
+		lipgloss.SetHasDarkBackground(output.HasDarkBackground())
+
+		options = append(options, tea.WithInput(tty), tea.WithOutput(tty))
+	}
+
 	paginator := NewPaginator(page)
-	p := tea.NewProgram(paginator, tea.WithAltScreen())
+	p := tea.NewProgram(paginator, options...)
+	if _, err := p.Run(); err != nil {
+		return err
+	}
+
+	if paginator.output == "" {
+		return nil
+	}
+
+	output := paginator.output
+	if !strings.HasSuffix(output, "\n") {
+		output += "\n"
+	}
 
-	_, err := p.Run()
+	_, err := os.Stdout.WriteString(output)
 	return err
 }
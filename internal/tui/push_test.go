@@ -0,0 +1,50 @@
+package tui
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/pomdtr/sunbeam/pkg/sunbeam"
+)
+
+func TestNewPushedPage(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		action string
+		valid  bool
+	}{
+		{
+			name:   "list",
+			action: `{"type": "push", "list": {"items": [{"title": "a"}]}}`,
+			valid:  true,
+		},
+		{
+			name:   "detail",
+			action: `{"type": "push", "detail": {"markdown": "# Hello"}}`,
+			valid:  true,
+		},
+		{
+			// the key is matched case-insensitively when decoding
+			name:   "list item with a misspelled title",
+			action: `{"type": "push", "list": {"items": [{"Title": "a"}]}}`,
+		},
+		{
+			name:   "detail with a null field",
+			action: `{"type": "push", "detail": {"markdown": null}}`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var action sunbeam.Action
+			if err := json.Unmarshal([]byte(tc.action), &action); err != nil {
+				t.Fatalf("failed to decode action: %v", err)
+			}
+
+			_, err := NewPushedPage(action.Push)
+			if tc.valid && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			} else if !tc.valid && err == nil {
+				t.Error("expected the page to be rejected")
+			}
+		})
+	}
+}
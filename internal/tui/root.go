@@ -1,22 +1,16 @@
 package tui
 
 import (
-	"bytes"
 	"encoding/json"
-	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"sort"
-	"strings"
 
-	"github.com/atotto/clipboard"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/muesli/termenv"
 	"github.com/pomdtr/sunbeam/internal/config"
-	"github.com/pomdtr/sunbeam/internal/extensions"
 	"github.com/pomdtr/sunbeam/internal/history"
-	"github.com/pomdtr/sunbeam/internal/utils"
 	"github.com/pomdtr/sunbeam/pkg/sunbeam"
 )
 
@@ -26,6 +20,7 @@ type RootList struct {
 	err           *Detail
 	list          *List
 	form          *Form
+	insert        bool
 
 	config    config.Config
 	history   history.History
@@ -42,6 +37,12 @@ func NewRootList(title string, history history.History, generator func() (config
 	}
 }
 
+// SetInsert prints the text of copy actions instead of copying it, for the
+// shell widgets to insert it in the command line.
+func (c *RootList) SetInsert(insert bool) {
+	c.insert = insert
+}
+
 func (c *RootList) Init() tea.Cmd {
 	termenv.DefaultOutput().SetWindowTitle(c.title)
 	return c.Reload()
@@ -54,6 +55,7 @@ func (c *RootList) Reload() tea.Cmd {
 	}
 
 	c.config = cfg
+	c.err = nil
 	c.history.Sort(rootItems)
 	if c.list != nil {
 		c.list.SetIsLoading(false)
@@ -70,10 +72,18 @@ func (c *RootList) Reload() tea.Cmd {
 
 func (c *RootList) Focus() tea.Cmd {
 	termenv.DefaultOutput().SetWindowTitle(c.title)
+	if c.list == nil {
+		return nil
+	}
+
 	return c.list.Focus()
 }
 
 func (c *RootList) Blur() tea.Cmd {
+	if c.list == nil {
+		return nil
+	}
+
 	return c.list.SetIsLoading(false)
 }
 
@@ -106,7 +116,7 @@ func (c *RootList) Update(msg tea.Msg) (Page, tea.Cmd) {
 		case "esc":
 			if c.form != nil {
 				c.form = nil
-				return c, c.list.Focus()
+				return c, c.Focus()
 			}
 		case "ctrl+s":
 			if c.form != nil {
@@ -118,308 +128,37 @@ func (c *RootList) Update(msg tea.Msg) (Page, tea.Cmd) {
 					return err
 				}
 
-				termenv.DefaultOutput().SetWindowTitle(c.title)
-				c.list.Focus()
-
+				c.Focus()
 				return ReloadMsg{}
 			})
 		case "ctrl+r":
-			return c, tea.Batch(c.list.SetIsLoading(true), c.Reload())
+			return c, c.reload(nil, nil)
 		}
 	case ReloadMsg:
-		return c, tea.Batch(c.list.SetIsLoading(true), c.Reload())
+		return c, c.reload(nil, nil)
+	case sequenceStepDoneMsg:
+		return c, msg.next()
+	case closeFormMsg:
+		c.form = nil
+		return c, c.Focus()
 	case sunbeam.Action:
-		selection, ok := c.list.Selection()
-		if !ok {
-			return c, nil
-		}
-		c.history.Update(selection.Id)
-		if err := c.history.Save(); err != nil {
-			return c, c.SetError(err)
-		}
-
-		switch msg.Type {
-		case sunbeam.ActionTypeRun:
-			extensionConfig := c.config.Extensions[msg.Run.Extension]
-			extension, err := extensions.LoadExtension(extensionConfig.Origin)
-			if err != nil {
-				return c, c.SetError(fmt.Errorf("failed to load extension: %w", err))
-			}
-
-			preferences := extensionConfig.Preferences
-			if preferences == nil {
-				preferences = make(map[string]any)
-			}
-
-			envs, err := ExtractPreferencesFromEnv(msg.Run.Extension, extension)
-			if err != nil {
-				return c, c.SetError(err)
-			}
-			for name, value := range envs {
-				preferences[name] = value
-			}
-
-			missingPreferences := FindMissingPreferences(extension.Manifest.Preferences, preferences)
-			for _, preference := range missingPreferences {
-				if preference.Optional {
-					continue
-				}
-
-				c.form = NewForm(func(values map[string]any) tea.Msg {
-					if extensionConfig.Preferences == nil {
-						extensionConfig.Preferences = make(map[string]any)
-					}
-
-					for k, v := range values {
-						extensionConfig.Preferences[k] = v
-					}
-
-					c.config.Extensions[msg.Run.Extension] = extensionConfig
-					if config.Path == "" {
-						return msg
-					}
-
-					if err := c.config.Save(); err != nil {
-						return err
-					}
-
-					return msg
-				}, missingPreferences...)
-
-				c.form.SetSize(c.width, c.height)
-				return c, c.form.Init()
-			}
-
-			command, ok := extension.Command(msg.Run.Command)
-			if !ok {
-				return c, c.SetError(fmt.Errorf("command %s not found", msg.Run.Command))
-			}
-
-			missingParams := FindMissingInputs(command.Params, msg.Run.Params)
-			for _, param := range missingParams {
-				if param.Optional {
-					continue
-				}
-
-				c.form = NewForm(func(values map[string]any) tea.Msg {
-					params := make(map[string]any)
-					for k, v := range msg.Run.Params {
-						params[k] = v
-					}
-
-					for k, v := range values {
-						params[k] = v
-					}
-
-					props := msg.Run
-					props.Params = params
-					return sunbeam.Action{
-						Title: msg.Title,
-						Type:  sunbeam.ActionTypeRun,
-						Run:   props,
-					}
-				}, missingParams...)
-
-				c.form.SetSize(c.width, c.height)
-				return c, c.form.Init()
-			}
-			c.form = nil
-
-			input := sunbeam.Payload{
-				Command:     command.Name,
-				Params:      make(map[string]any),
-				Preferences: preferences,
-			}
-
-			for k, v := range msg.Run.Params {
-				input.Params[k] = v
-			}
-
-			switch command.Mode {
-			case sunbeam.CommandModeSearch, sunbeam.CommandModeFilter, sunbeam.CommandModeDetail:
-				runner := NewRunner(extension, input)
-				return c, PushPageCmd(runner)
-			case sunbeam.CommandModeSilent:
-				return c, func() tea.Msg {
-					output, err := extension.Output(input)
-					if err != nil {
-						return PushPageMsg{NewErrorPage(err)}
-					}
-
-					if msg.Run.Exit {
-						return ExitMsg{}
-					}
-
-					if len(output) > 0 {
-						output = bytes.Trim(output, "\n")
-						rows := strings.Split(string(output), "\n")
-						return ShowNotificationMsg{rows[len(rows)-1]}
-					}
-
-					return nil
-				}
-			case sunbeam.CommandModeTTY:
-				cmd, err := extension.Cmd(input)
-
-				if err != nil {
-					c.err = NewErrorPage(err)
-					c.err.SetSize(c.width, c.height)
-					return c, c.err.Init()
-				}
-
-				return c, tea.ExecProcess(cmd, func(err error) tea.Msg {
-					if err != nil {
-						return PushPageMsg{NewErrorPage(err)}
-					}
-
-					if msg.Run.Exit {
-						return ExitMsg{}
-					}
-
-					termenv.DefaultOutput().SetWindowTitle(c.title)
-					return c.list.Focus()
-				})
-			}
-		case sunbeam.ActionTypeCopy:
-			return c, func() tea.Msg {
-				if err := clipboard.WriteAll(msg.Copy.Text); err != nil {
-					return err
-				}
-
-				if msg.Copy.Exit {
-					return ExitMsg{}
-				}
-
-				return ShowNotificationMsg{"Copied!"}
-			}
-		case sunbeam.ActionTypeEdit:
-			editCmd := exec.Command("sunbeam", "edit", msg.Edit.Path)
-			return c, tea.ExecProcess(editCmd, func(err error) tea.Msg {
-				if err != nil {
-					return err
-				}
-
-				if msg.Edit.Reload {
-					return c.Reload()
-				}
-
-				if msg.Edit.Exit {
-					return ExitMsg{}
-				}
-
-				return nil
-			})
-		case sunbeam.ActionTypeConfig:
-			extensionConfig, ok := c.config.Extensions[msg.Config.Extension]
+		// the actions of the error page do not belong to an item
+		if c.err == nil {
+			selection, ok := c.list.Selection()
 			if !ok {
-				return c, c.SetError(fmt.Errorf("extension %s not found", msg.Config.Extension))
-			}
-
-			extension, err := extensions.LoadExtension(extensionConfig.Origin)
-			if err != nil {
-				return c, c.SetError(fmt.Errorf("failed to load extension %s", msg.Config.Extension))
-			}
-
-			inputs := make([]sunbeam.Input, 0)
-			for _, input := range extension.Manifest.Preferences {
-				if preference := extensionConfig.Preferences[input.Name]; preference != nil {
-					input.Default = preference
-				}
-				input.Optional = false
-				inputs = append(inputs, input)
-			}
-
-			c.form = NewForm(func(values map[string]any) tea.Msg {
-				c.form = nil
-				extensionConfig.Preferences = values
-				c.config.Extensions[msg.Config.Extension] = extensionConfig
-				if err := c.config.Save(); err != nil {
-					return err
-				}
-
-				return nil
-			}, inputs...)
-			c.form.SetSize(c.width, c.height)
-			return c, c.form.Init()
-		case sunbeam.ActionTypeExec:
-			cmd := exec.Command("sh", "-c", msg.Exec.Command)
-			cmd.Dir = msg.Exec.Dir
-			if strings.HasPrefix(cmd.Dir, "~") {
-				homeDir, err := os.UserHomeDir()
-				if err != nil {
-					return c, c.SetError(err)
-				}
-
-				cmd.Dir = filepath.Join(homeDir, strings.TrimPrefix(cmd.Dir, "~"))
+				return c, nil
 			}
-
-			if !filepath.IsAbs(cmd.Dir) {
-				wd, err := os.Getwd()
-				if err != nil {
-					return c, c.SetError(err)
-				}
-
-				cmd.Dir = filepath.Join(wd, cmd.Dir)
-			}
-
-			if !msg.Exec.Interactive {
-				return c, func() tea.Msg {
-					output, err := cmd.Output()
-					if err != nil {
-						return err
-					}
-
-					if msg.Exec.Exit {
-						return ExitMsg{}
-					}
-
-					if len(output) > 0 {
-						output = bytes.Trim(output, "\n")
-						rows := strings.Split(string(output), "\n")
-						return ShowNotificationMsg{rows[len(rows)-1]}
-					}
-
-					return nil
-				}
-			}
-
-			return c, tea.ExecProcess(cmd, func(err error) tea.Msg {
-				if err != nil {
-					return err
-				}
-
-				if msg.Exec.Exit {
-					return ExitMsg{}
-				}
-
-				termenv.DefaultOutput().SetWindowTitle(c.title)
-				return nil
-			})
-		case sunbeam.ActionTypeOpen:
-			return c, func() tea.Msg {
-				if msg.Open.Url != "" {
-					if err := utils.Open(msg.Open.Url); err != nil {
-						return err
-					}
-
-					return ExitMsg{}
-				} else if msg.Open.Path != "" {
-					if err := utils.Open(fmt.Sprintf("file://%s", msg.Open.Path)); err != nil {
-						return err
-					}
-
-					return ExitMsg{}
-				} else {
-					return fmt.Errorf("invalid target")
-				}
+			c.history.Update(selection.Id)
+			if err := c.history.Save(); err != nil {
+				return c, c.SetError(err)
 			}
-		case sunbeam.ActionTypeExit:
-			return c, ExitCmd
-		case sunbeam.ActionTypeReload:
-			return c, tea.Sequence(c.err.SetIsLoading(true), c.Reload())
-		default:
-			return c, nil
 		}
+
+		c.form = nil
+		return c, dispatchAction(c, c.actionContext(nil), msg)
+	case stepActionMsg:
+		c.form = nil
+		return c, dispatchAction(c, c.actionContext(msg.step), msg.action)
 	case error:
 		c.err = NewErrorPage(msg)
 		c.err.SetSize(c.width, c.height)
@@ -448,6 +187,62 @@ func (c *RootList) Update(msg tea.Msg) (Page, tea.Cmd) {
 	return c, nil
 }
 
+func (c *RootList) actionContext(step *sequenceStep) actionContext {
+	var query string
+	if c.list != nil {
+		query = c.list.Query()
+	}
+
+	return actionContext{
+		config: func() (config.Config, error) {
+			return c.config, nil
+		},
+		query:  query,
+		width:  c.width,
+		height: c.height,
+		insert: c.insert,
+		step:   step,
+	}
+}
+
+func (c *RootList) showForm(form *Form) tea.Cmd {
+	c.form = form
+	return c.form.Init()
+}
+
+func (c *RootList) showError(err error) tea.Cmd {
+	return c.SetError(err)
+}
+
+// reload regenerates the items. The error page is replaced by the items once
+// they are generated again, the list is missing if they never were.
+func (c *RootList) reload(params map[string]any, step *sequenceStep) tea.Cmd {
+	var loading tea.Cmd
+	if c.list != nil {
+		loading = c.list.SetIsLoading(true)
+	}
+	cmd := tea.Sequence(loading, c.Reload())
+	if step == nil {
+		return cmd
+	}
+
+	// the items are generated synchronously, the reload is already over
+	var err error
+	if c.err != nil {
+		err = c.err.err
+	}
+
+	return tea.Sequence(cmd, stepDoneCmd(step, err))
+}
+
+func (c *RootList) refocus() tea.Msg {
+	if cmd := c.Focus(); cmd != nil {
+		return cmd()
+	}
+
+	return nil
+}
+
 func (c *RootList) View() string {
 	if c.err != nil {
 		return c.err.View()
@@ -0,0 +1,36 @@
+package tui
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/pomdtr/sunbeam/internal/config"
+	"github.com/pomdtr/sunbeam/internal/history"
+	"github.com/pomdtr/sunbeam/pkg/sunbeam"
+)
+
+func TestRootListReloadsFromErrorPage(t *testing.T) {
+	err := errors.New("invalid config")
+	root := NewRootList("Sunbeam", history.History{}, func() (config.Config, []sunbeam.ListItem, error) {
+		if err != nil {
+			return config.Config{}, nil, err
+		}
+
+		return config.Config{}, []sunbeam.ListItem{{Title: "Item"}}, nil
+	})
+
+	root.Init()
+	if root.err == nil || root.list != nil {
+		t.Fatal("expected an error page")
+	}
+
+	err = nil
+	root.Update(ReloadMsg{})
+	if root.err != nil {
+		t.Fatalf("expected the error page to be replaced, got %v", root.err.err)
+	}
+
+	if root.list == nil || len(root.list.filter.items) != 1 {
+		t.Fatal("expected the items to be loaded")
+	}
+}
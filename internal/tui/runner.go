@@ -6,14 +6,13 @@ import (
 	"errors"
 	"fmt"
 	"os/exec"
+	"strings"
+	"time"
 
-	"github.com/acarl005/stripansi"
-	"github.com/atotto/clipboard"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/muesli/termenv"
 	"github.com/pomdtr/sunbeam/internal/extensions"
 	"github.com/pomdtr/sunbeam/internal/schemas"
-	"github.com/pomdtr/sunbeam/internal/utils"
 	"github.com/pomdtr/sunbeam/pkg/sunbeam"
 )
 
@@ -21,7 +20,30 @@ type Runner struct {
 	embed         Page
 	form          *Form
 	width, height int
+	ctx           context.Context
 	cancel        context.CancelFunc
+	emptyText     string
+	nextCursor    string
+	streaming     bool
+	static        bool
+	insert        bool
+
+	// the items of the last reload, and whether they replace cached ones
+	streamed     []sunbeam.ListItem
+	streamHeader *sunbeam.List
+	collecting   bool
+	revalidating bool
+
+	// background refreshes, paused while the page is blurred
+	refreshInterval time.Duration
+	refreshID       int
+	refreshing      bool
+	updatedAt       time.Time
+	refreshedAt     time.Time
+	refreshErr      error
+
+	// the sequence step waiting for the page to be reloaded
+	reloadStep *sequenceStep
 
 	extension extensions.Extension
 	command   sunbeam.CommandSpec
@@ -58,6 +80,82 @@ func NewRunner(extension extensions.Extension, input sunbeam.Payload) *Runner {
 	}
 }
 
+// NewStaticRunner embeds a page provided inline by a push action. The page
+// is never reloaded, but its actions are run in the context of the extension.
+func NewStaticRunner(extension extensions.Extension, input sunbeam.Payload, page Page) *Runner {
+	command, _ := extension.Command(input.Command)
+	return &Runner{
+		embed:     page,
+		extension: extension,
+		command:   command,
+		input:     input,
+		static:    true,
+	}
+}
+
+// NewPushedPage builds the page of a push action, after validating it. The
+// json sent by the extension is validated, the fields dropped while decoding
+// it would not be caught otherwise.
+func NewPushedPage(push *sunbeam.PushAction) (Page, error) {
+	if push.List != nil {
+		bts := push.RawList()
+		if bts == nil {
+			var err error
+			if bts, err = json.Marshal(push.List); err != nil {
+				return nil, err
+			}
+		}
+
+		if err := schemas.ValidateList(bts); err != nil {
+			return nil, err
+		}
+
+		list := NewList(push.List.Items...)
+		list.SetActions(push.List.Actions...)
+		list.SetShowDetail(push.List.ShowDetail)
+		list.SetLayout(push.List.Layout, push.List.Columns)
+		list.SetEmptyText(push.List.EmptyText)
+		return list, nil
+	}
+
+	if push.Detail != nil {
+		bts := push.RawDetail()
+		if bts == nil {
+			var err error
+			if bts, err = json.Marshal(push.Detail); err != nil {
+				return nil, err
+			}
+		}
+
+		if err := schemas.ValidateDetail(bts); err != nil {
+			return nil, err
+		}
+
+		return newDetailPage(*push.Detail), nil
+	}
+
+	return nil, fmt.Errorf("push action requires a list or a detail")
+}
+
+func newDetailPage(detail sunbeam.Detail) *Detail {
+	if detail.Markdown != "" {
+		page := NewDetail(detail.Markdown, detail.Actions...)
+		page.Markdown = true
+		page.Metadata = detail.Metadata
+		return page
+	}
+
+	page := NewDetail(detail.Text, detail.Actions...)
+	page.Metadata = detail.Metadata
+	return page
+}
+
+// SetInsert prints the text of copy actions instead of copying it, for the
+// shell widgets to insert it in the command line.
+func (c *Runner) SetInsert(insert bool) {
+	c.insert = insert
+}
+
 func (c *Runner) SetIsLoading(isLoading bool) tea.Cmd {
 	switch page := c.embed.(type) {
 	case *Detail:
@@ -71,7 +169,7 @@ func (c *Runner) SetIsLoading(isLoading bool) tea.Cmd {
 
 func (c *Runner) Init() tea.Cmd {
 	termenv.DefaultOutput().SetWindowTitle(fmt.Sprintf("%s - %s", c.command.Title, c.extension.Manifest.Title))
-	return tea.Batch(c.Reload(), c.embed.Init())
+	return tea.Batch(c.Reload(), c.embed.Init(), c.tick())
 }
 
 func (c *Runner) Focus() tea.Cmd {
@@ -79,14 +177,38 @@ func (c *Runner) Focus() tea.Cmd {
 		return nil
 	}
 	termenv.DefaultOutput().SetWindowTitle(fmt.Sprintf("%s - %s", c.command.Title, c.extension.Manifest.Title))
-	return c.embed.Focus()
+	c.refreshID++
+	return tea.Batch(c.embed.Focus(), c.tick())
 }
 
 func (c *Runner) Blur() tea.Cmd {
-	c.cancel()
+	c.streaming = false
+	c.refreshing = false
+	c.refreshID++
+	if c.cancel != nil {
+		c.cancel()
+	}
 	return nil
 }
 
+type refreshTickMsg struct {
+	id int
+}
+
+// tick schedules the next refresh check, if the page has a refresh interval.
+// Ticks scheduled before the page was blurred or loaded are ignored, so that
+// a single loop runs at a time.
+func (c *Runner) tick() tea.Cmd {
+	if c.static || c.refreshInterval <= 0 {
+		return nil
+	}
+
+	id := c.refreshID
+	return tea.Tick(time.Second, func(time.Time) tea.Msg {
+		return refreshTickMsg{id: id}
+	})
+}
+
 func (c *Runner) SetSize(w int, h int) {
 	c.width = w
 	c.height = h
@@ -113,6 +235,10 @@ func (c *Runner) Update(msg tea.Msg) (Page, tea.Cmd) {
 			}
 			return c, PopPageCmd
 		case "ctrl+s":
+			if c.static || c.extension.Type == extensions.ExtensionTypeHttp {
+				break
+			}
+
 			editCmd := exec.Command("sunbeam", "edit", c.extension.Entrypoint)
 			return c, tea.ExecProcess(editCmd, func(err error) tea.Msg {
 				if err != nil {
@@ -124,204 +250,255 @@ func (c *Runner) Update(msg tea.Msg) (Page, tea.Cmd) {
 					return err
 				}
 				c.extension = extension
+				extensions.StopProcess(c.extension.Entrypoint)
 
 				return ReloadMsg{}
 			})
+		case "ctrl+l":
+			if c.static || c.extension.LogPath() == "" {
+				break
+			}
+
+			return c, func() tea.Msg {
+				page, err := c.logPage()
+				if err != nil {
+					return err
+				}
+
+				return PushPageMsg{NewStaticRunner(c.extension, c.input, page)}
+			}
 		case "ctrl+r":
+			if c.static {
+				break
+			}
+
 			return c, func() tea.Msg {
 				manifest, err := extensions.ExtractManifest(c.extension.Entrypoint)
 				if err != nil {
 					return err
 				}
 				c.extension.Manifest = manifest
+				extensions.StopProcess(c.extension.Entrypoint)
 
 				return ReloadMsg{}
 			}
 		}
+	case refreshTickMsg:
+		if msg.id != c.refreshID {
+			return c, nil
+		}
+
+		if c.updatedAt.IsZero() {
+			return c, c.tick()
+		}
+
+		if c.refreshErr != nil {
+			c.setStatusInfo(fmt.Sprintf("refresh failed: %s", c.refreshErr))
+		} else {
+			c.setStatusInfo(fmt.Sprintf("updated %s ago", formatElapsed(time.Since(c.updatedAt))))
+		}
+
+		if time.Since(c.refreshedAt) < c.refreshInterval || c.refreshing || c.streaming {
+			return c, c.tick()
+		}
+
+		return c, tea.Batch(c.refresh(), c.tick())
 	case ReloadMsg:
 		return c, c.Reload()
+	case detailLoadedMsg:
+		page := newDetailPage(msg.detail)
+		page.SetSize(c.width, c.height)
+		if previous, ok := c.embed.(*Detail); ok && msg.refresh {
+			page.viewport.SetYOffset(previous.viewport.YOffset)
+		}
+
+		c.embed = page
+		c.revalidating = false
+		c.refreshInterval = time.Duration(msg.detail.RefreshInterval) * time.Second
+		return c, tea.Batch(page.Init(), c.loaded(), c.finishReloadStep(nil))
+	case ListStreamMsg:
+		return c, c.handleListStream(msg)
+	case sequenceStepDoneMsg:
+		return c, msg.next()
 	case Page:
+		c.revalidating = false
 		c.embed = msg
 		c.embed.SetSize(c.width, c.height)
 		return c, c.embed.Init()
+	case closeFormMsg:
+		c.form = nil
+		return c, c.embed.Focus()
 	case sunbeam.Action:
-		switch msg.Type {
-		case sunbeam.ActionTypeRun:
-			command, ok := c.extension.Command(msg.Run.Command)
-			if !ok {
-				c.embed = NewErrorPage(fmt.Errorf("command %s not found", msg.Run.Command))
-				c.embed.SetSize(c.width, c.height)
-				return c, c.embed.Init()
-			}
+		c.form = nil
+		return c, dispatchAction(c, c.actionContext(nil), msg)
+	case stepActionMsg:
+		c.form = nil
+		return c, dispatchAction(c, c.actionContext(msg.step), msg.action)
+	case error:
+		// the loaded page is kept when a background refresh fails
+		if c.refreshing {
+			c.refreshing = false
+			c.revalidating = false
+			c.collecting = false
+			c.refreshErr = msg
+			c.setStatusInfo(fmt.Sprintf("refresh failed: %s", msg))
+			return c, nil
+		}
 
-			missing := FindMissingInputs(command.Params, msg.Run.Params)
-			for _, param := range missing {
-				if param.Optional {
-					continue
-				}
+		var actions []sunbeam.Action
+		if errors.Is(msg, extensions.ErrProcessExited) {
+			actions = append(actions, sunbeam.Action{
+				Title:  "Restart Extension",
+				Type:   sunbeam.ActionTypeReload,
+				Reload: &sunbeam.ReloadAction{},
+			})
+		}
 
-				c.form = NewForm(func(values map[string]any) tea.Msg {
-					params := make(map[string]any)
-					for k, v := range msg.Run.Params {
-						params[k] = v
-					}
-
-					for k, v := range values {
-						params[k] = v
-					}
-
-					props := msg.Run
-					props.Params = params
-
-					return sunbeam.Action{
-						Title:  msg.Title,
-						Type:   sunbeam.ActionTypeRun,
-						Run:    props,
-						Reload: msg.Reload,
-					}
-				}, missing...)
-
-				c.form.SetSize(c.width, c.height)
-				return c, tea.Sequence(c.form.Init(), c.form.Focus())
-			}
-			c.form = nil
+		var timeoutErr extensions.TimeoutError
+		if errors.As(msg, &timeoutErr) && !c.static {
+			actions = append(actions, sunbeam.Action{
+				Title:  "Retry",
+				Type:   sunbeam.ActionTypeReload,
+				Reload: &sunbeam.ReloadAction{},
+			})
+		}
 
-			input := sunbeam.Payload{
-				Command:     msg.Run.Command,
-				Preferences: c.input.Preferences,
-				Params:      make(map[string]any),
-			}
+		c.refreshing = false
+		c.refreshInterval = 0
+		c.embed = NewErrorPage(msg, actions...)
+		c.embed.SetSize(c.width, c.height)
+		return c, tea.Batch(c.embed.Init(), c.finishReloadStep(msg))
+	}
 
-			for k, v := range msg.Run.Params {
-				input.Params[k] = v
-			}
+	if c.form != nil {
+		form, cmd := c.form.Update(msg)
+		c.form = form.(*Form)
+		return c, cmd
+	}
 
-			switch command.Mode {
-			case sunbeam.CommandModeSearch, sunbeam.CommandModeFilter, sunbeam.CommandModeDetail:
-				runner := NewRunner(c.extension, input)
+	var cmd tea.Cmd
+	c.embed, cmd = c.embed.Update(msg)
+	return c, cmd
+}
 
-				return c, PushPageCmd(runner)
-			case sunbeam.CommandModeSilent:
-				return c, func() tea.Msg {
-					_, err := c.extension.Output(input)
+// logPage renders the last log entry of the command, or of the extension if
+// the command has none.
+func (c *Runner) logPage() (Page, error) {
+	entries, err := extensions.ReadLogs(c.extension.LogPath())
+	if err != nil {
+		return nil, err
+	}
 
-					if err != nil {
-						return PushPageMsg{NewErrorPage(err)}
-					}
+	if len(entries) == 0 {
+		return NewDetail("No logs found"), nil
+	}
 
-					if msg.Run.Reload {
-						return ReloadMsg{}
-					}
+	entry := entries[len(entries)-1]
+	for i := len(entries) - 1; i >= 0; i-- {
+		if entries[i].Command == c.input.Command {
+			entry = entries[i]
+			break
+		}
+	}
 
-					if msg.Run.Exit {
-						return ExitMsg{}
-					}
+	payload, err := json.MarshalIndent(entry.Payload, "", "  ")
+	if err != nil {
+		return nil, err
+	}
 
-					return nil
-				}
-			case sunbeam.CommandModeTTY:
-				cmd, err := c.extension.Cmd(input)
-				if err != nil {
-					c.embed = NewErrorPage(err)
-					c.embed.SetSize(c.width, c.height)
-					return c, c.embed.Init()
-				}
+	var body strings.Builder
+	if entry.Error != "" {
+		fmt.Fprintf(&body, "## Error\n\n```\n%s\n```\n\n", entry.Error)
+	}
+	if entry.Stderr != "" {
+		fmt.Fprintf(&body, "## Stderr\n\n```\n%s\n```\n\n", strings.TrimRight(entry.Stderr, "\n"))
+	}
+	fmt.Fprintf(&body, "## Payload\n\n```json\n%s\n```\n", payload)
 
-				return c, tea.ExecProcess(cmd, func(err error) tea.Msg {
-					if err != nil {
-						return PushPageMsg{NewErrorPage(err)}
-					}
+	detail := NewDetail(body.String(), sunbeam.Action{
+		Title: "Copy Stderr",
+		Type:  sunbeam.ActionTypeCopy,
+		Copy:  &sunbeam.CopyAction{Text: entry.Stderr},
+	})
+	detail.Markdown = true
+	detail.Metadata = []sunbeam.MetadataItem{
+		{Type: sunbeam.MetadataItemLabel, Title: "Command", Text: entry.Command},
+		{Type: sunbeam.MetadataItemLabel, Title: "Time", Text: entry.Time.Format(time.DateTime)},
+		{Type: sunbeam.MetadataItemLabel, Title: "Duration", Text: entry.Duration.Round(time.Millisecond).String()},
+		{Type: sunbeam.MetadataItemLabel, Title: "Exit Code", Text: fmt.Sprint(entry.ExitCode)},
+		{Type: sunbeam.MetadataItemLabel, Title: "Log", Text: c.extension.LogPath()},
+	}
 
-					if msg.Run.Reload {
-						c.embed.Focus()
-						return ReloadMsg{}
-					}
+	return detail, nil
+}
 
-					if msg.Run.Exit {
-						return ExitMsg{}
-					}
+func (c *Runner) actionContext(step *sequenceStep) actionContext {
+	return actionContext{
+		extension:   c.extension,
+		preferences: c.input.Preferences,
+		config:      loadConfig,
+		query:       c.query(),
+		width:       c.width,
+		height:      c.height,
+		insert:      c.insert,
+		step:        step,
+	}
+}
 
-					termenv.DefaultOutput().SetWindowTitle(fmt.Sprintf("%s - %s", c.command.Title, c.extension.Manifest.Title))
-					return c.embed.Focus()
-				})
-			}
-		case sunbeam.ActionTypeEdit:
-			editCmd := exec.Command("sunbeam", "edit", msg.Edit.Path)
-			return c, tea.ExecProcess(editCmd, func(err error) tea.Msg {
-				if err != nil {
-					return err
-				}
+func (c *Runner) query() string {
+	if list, ok := c.embed.(*List); ok {
+		return list.Query()
+	}
 
-				if msg.Edit.Reload {
-					c.embed.Focus()
-					return c.Reload()
-				}
+	return c.input.Query
+}
 
-				if msg.Edit.Exit {
-					return ExitMsg{}
-				}
+func (c *Runner) showForm(form *Form) tea.Cmd {
+	c.form = form
+	return c.form.Init()
+}
 
-				return c.embed.Focus()
-			})
-		case sunbeam.ActionTypeCopy:
-			return c, func() tea.Msg {
-				if err := clipboard.WriteAll(msg.Copy.Text); err != nil {
-					return err
-				}
+func (c *Runner) showError(err error) tea.Cmd {
+	c.embed = NewErrorPage(err)
+	c.embed.SetSize(c.width, c.height)
+	return c.embed.Init()
+}
 
-				if msg.Copy.Exit {
-					return ExitMsg{}
-				}
+func (c *Runner) reload(params map[string]any, step *sequenceStep) tea.Cmd {
+	if c.input.Params == nil {
+		c.input.Params = make(map[string]any)
+	}
 
-				return ShowNotificationMsg{"Copied!"}
-			}
-		case sunbeam.ActionTypeOpen:
-			return c, func() tea.Msg {
-				if msg.Open.Url != "" {
-					if err := utils.Open(msg.Open.Url); err != nil {
-						return err
-					}
-
-					return ExitMsg{}
-				} else if msg.Open.Path != "" {
-					if err := utils.Open(fmt.Sprintf("file://%s", msg.Open.Path)); err != nil {
-						return err
-					}
-
-					return ExitMsg{}
-				} else {
-					return fmt.Errorf("invalid target")
-				}
-			}
-		case sunbeam.ActionTypeExit:
-			return c, ExitCmd
-		case sunbeam.ActionTypeReload:
-			if c.input.Params == nil {
-				c.input.Params = make(map[string]any)
-			}
+	for k, v := range params {
+		c.input.Params[k] = v
+	}
 
-			for k, v := range msg.Reload.Params {
-				c.input.Params[k] = v
-			}
+	c.reloadStep = step
+	if c.static {
+		return c.finishReloadStep(nil)
+	}
 
-			return c, c.Reload()
-		}
+	return c.Reload()
+}
 
-	case error:
-		c.embed = NewErrorPage(msg)
-		c.embed.SetSize(c.width, c.height)
-		return c, c.embed.Init()
+// finishReloadStep completes the sequence step waiting for the reload, if any.
+func (c *Runner) finishReloadStep(err error) tea.Cmd {
+	step := c.reloadStep
+	if step == nil {
+		return nil
 	}
 
-	if c.form != nil {
-		form, cmd := c.form.Update(msg)
-		c.form = form.(*Form)
-		return c, cmd
+	c.reloadStep = nil
+	return stepDoneCmd(step, err)
+}
+
+func (c *Runner) refocus() tea.Msg {
+	termenv.DefaultOutput().SetWindowTitle(fmt.Sprintf("%s - %s", c.command.Title, c.extension.Manifest.Title))
+	if cmd := c.embed.Focus(); cmd != nil {
+		return cmd()
 	}
 
-	var cmd tea.Cmd
-	c.embed, cmd = c.embed.Update(msg)
-	return c, cmd
+	return nil
 }
 
 func (c *Runner) View() string {
@@ -333,35 +510,68 @@ func (c *Runner) View() string {
 }
 
 func (c *Runner) Reload() tea.Cmd {
-	return tea.Sequence(c.SetIsLoading(true), func() tea.Msg {
-		if c.cancel != nil {
-			c.cancel()
-		}
+	if c.static {
+		return nil
+	}
 
-		ctx, cancel := context.WithCancel(context.Background())
-		c.cancel = cancel
-		defer cancel()
+	c.refreshing = false
+	c.revalidating = c.loadCache()
+	return tea.Sequence(c.SetIsLoading(true), c.load(false))
+}
 
-		cmd, err := c.extension.CmdContext(ctx, c.input)
-		if err != nil {
-			return err
-		}
+// refresh reloads the page in the background. The displayed items are kept
+// until the fresh ones are loaded, so the query and the selection are preserved.
+func (c *Runner) refresh() tea.Cmd {
+	c.refreshing = true
+	c.refreshedAt = time.Now()
+	c.revalidating = c.command.Mode != sunbeam.CommandModeDetail
+	return c.load(true)
+}
 
-		output, err := cmd.Output()
-		if err != nil {
-			if errors.Is(ctx.Err(), context.Canceled) {
-				return nil
-			}
-			var exitErr *exec.ExitError
-			if errors.As(err, &exitErr) {
-				return fmt.Errorf("command failed: %s", stripansi.Strip(string(exitErr.Stderr)))
-			}
+// loaded records that the page is up to date, and restarts the refresh loop
+// with the refresh interval of the page.
+func (c *Runner) loaded() tea.Cmd {
+	c.refreshing = false
+	c.refreshErr = nil
+	c.updatedAt = time.Now()
+	c.refreshedAt = c.updatedAt
+	c.setStatusInfo("")
+
+	c.refreshID++
+	return c.tick()
+}
 
-			return err
-		}
+type detailLoadedMsg struct {
+	detail  sunbeam.Detail
+	refresh bool
+}
+
+// load cancels the running load, and returns the command loading the page.
+// The state of the runner is only updated here, the command works on copies.
+func (c *Runner) load(refresh bool) tea.Cmd {
+	if c.cancel != nil {
+		c.cancel()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c.ctx, c.cancel = ctx, cancel
+	c.streaming = c.command.Mode != sunbeam.CommandModeDetail
+
+	switch c.command.Mode {
+	case sunbeam.CommandModeDetail:
+		extension, input := c.extension, c.input
+		return func() tea.Msg {
+			defer cancel()
+
+			output, err := extension.OutputContext(ctx, input)
+			if err != nil {
+				if errors.Is(ctx.Err(), context.Canceled) {
+					return nil
+				}
+
+				return err
+			}
 
-		switch c.command.Mode {
-		case sunbeam.CommandModeDetail:
 			if err := schemas.ValidateDetail(output); err != nil {
 				return err
 			}
@@ -371,58 +581,242 @@ func (c *Runner) Reload() tea.Cmd {
 				return err
 			}
 
-			if detail.Markdown != "" {
-				page := NewDetail(detail.Markdown, detail.Actions...)
-				page.Markdown = true
-				return page
-			}
+			// the cache is best-effort, the page is loaded even if it can not be written
+			_ = extension.WriteCache(input, output)
 
-			page := NewDetail(detail.Text, detail.Actions...)
-			return page
-		case sunbeam.CommandModeSearch, sunbeam.CommandModeFilter:
-			if err := schemas.ValidateList(output); err != nil {
-				return err
-			}
+			return detailLoadedMsg{detail: detail, refresh: refresh}
+		}
+	case sunbeam.CommandModeSearch, sunbeam.CommandModeFilter:
+		return c.streamList(ctx, c.input, true)
+	default:
+		cancel()
+		return func() tea.Msg {
+			return fmt.Errorf("invalid view type")
+		}
+	}
+}
 
-			var list sunbeam.List
-			if err := json.Unmarshal(output, &list); err != nil {
-				return err
-			}
+// loadCache renders the cached output of the command, if any. It reports
+// whether the cached output is displayed.
+func (c *Runner) loadCache() bool {
+	output, ok := c.extension.ReadCache(c.input)
+	if !ok {
+		return false
+	}
 
-			var page *List
-			if embed, ok := c.embed.(*List); ok {
-				page = embed
-				page.SetItems(list.Items...)
-				page.SetIsLoading(false)
-				page.SetEmptyText(list.EmptyText)
-				page.SetActions(list.Actions...)
-				page.SetShowDetail(list.ShowDetail)
-
-				if c.command.Mode == sunbeam.CommandModeSearch {
-					page.OnQueryChange = func(query string) tea.Cmd {
-						c.input.Query = query
-						return c.Reload()
-					}
-					page.ResetSelection()
-				}
+	switch c.command.Mode {
+	case sunbeam.CommandModeDetail:
+		var detail sunbeam.Detail
+		if err := json.Unmarshal(output, &detail); err != nil {
+			return false
+		}
 
-				return nil
-			}
+		c.embed = newDetailPage(detail)
+		c.embed.SetSize(c.width, c.height)
+		c.refreshInterval = time.Duration(detail.RefreshInterval) * time.Second
+		return true
+	case sunbeam.CommandModeSearch, sunbeam.CommandModeFilter:
+		var list sunbeam.List
+		if err := json.Unmarshal(output, &list); err != nil {
+			return false
+		}
+
+		page, ok := c.embed.(*List)
+		if !ok {
+			page = NewList()
+			page.SetSize(c.width, c.height)
+			c.embed = page
+		}
+
+		c.setListHeader(page, list)
+		page.ReplaceItems(list.Items...)
+		page.SetEmptyText(list.EmptyText)
+		return true
+	default:
+		return false
+	}
+}
+
+// writeListCache stores the streamed list. Errors are ignored, the cache is
+// best-effort and should never replace a loaded list.
+func (c *Runner) writeListCache() {
+	var list sunbeam.List
+	if c.streamHeader != nil {
+		list = *c.streamHeader
+	}
+	list.Items = c.streamed
+
+	output, err := json.Marshal(list)
+	if err != nil {
+		return
+	}
+
+	_ = c.extension.WriteCache(c.input, output)
+}
+
+func (c *Runner) setListHeader(page *List, header sunbeam.List) {
+	c.nextCursor = header.NextCursor
+	page.SetActions(header.Actions...)
+	page.SetShowDetail(header.ShowDetail)
+	page.SetLayout(header.Layout, header.Columns)
+	c.emptyText = header.EmptyText
+	c.refreshInterval = time.Duration(header.RefreshInterval) * time.Second
+}
+
+func (c *Runner) setStatusInfo(info string) {
+	switch page := c.embed.(type) {
+	case *List:
+		page.SetStatusInfo(info)
+	case *Detail:
+		page.SetStatusInfo(info)
+	}
+}
+
+func formatElapsed(elapsed time.Duration) string {
+	if elapsed < time.Minute {
+		return fmt.Sprintf("%ds", int(elapsed.Seconds()))
+	}
+
+	if elapsed < time.Hour {
+		return fmt.Sprintf("%dm", int(elapsed.Minutes()))
+	}
+
+	return fmt.Sprintf("%dh", int(elapsed.Hours()))
+}
+
+// LoadNextPage fetches the page following the last loaded one, if any.
+// The request shares the context of the current reload, so it is cancelled
+// when the query changes.
+func (c *Runner) LoadNextPage() tea.Cmd {
+	if c.nextCursor == "" || c.streaming {
+		return nil
+	}
+
+	if c.ctx == nil || c.ctx.Err() != nil {
+		c.ctx, c.cancel = context.WithCancel(context.Background())
+	}
+
+	ctx := c.ctx
+	input := c.input
+	input.Cursor = c.nextCursor
+	// the cursor is set again by the header of the page, if it has one
+	c.nextCursor = ""
+	c.streaming = true
+
+	return tea.Sequence(c.SetIsLoading(true), c.streamList(ctx, input, false))
+}
+
+func (c *Runner) streamList(ctx context.Context, input sunbeam.Payload, reset bool) tea.Cmd {
+	extension := c.extension
+	return func() tea.Msg {
+		// persistent and http extensions answer with a single response
+		if !extension.OneShot() {
+			return streamListOutput(ctx, func() ([]byte, error) {
+				return extension.OutputContext(ctx, input)
+			}, reset)
+		}
+
+		return streamList(ctx, extension, input, reset)
+	}
+}
+
+func (c *Runner) handleListStream(msg ListStreamMsg) tea.Cmd {
+	if msg.ctx.Err() != nil {
+		return nil
+	}
+
+	if msg.err != nil {
+		c.streaming = false
+		return func() tea.Msg {
+			return msg.err
+		}
+	}
+
+	var cmds []tea.Cmd
+	page, ok := c.embed.(*List)
+	if !ok {
+		page = NewList()
+		page.SetEmptyText("Loading...")
+		page.SetSize(c.width, c.height)
+		c.embed = page
+		cmds = append(cmds, page.Init())
+	}
+
+	if c.command.Mode == sunbeam.CommandModeSearch {
+		page.OnQueryChange = func(query string) tea.Cmd {
+			c.input.Query = query
+			return c.Reload()
+		}
+	}
+	page.OnNextPage = c.LoadNextPage
+
+	if msg.reset {
+		c.streamed = nil
+		c.streamHeader = nil
+		c.collecting = true
+	}
+
+	if c.collecting {
+		c.streamed = append(c.streamed, msg.items...)
+		if msg.header != nil {
+			c.streamHeader = msg.header
+		}
+	}
 
-			page = NewList(list.Items...)
-			page.SetEmptyText(list.EmptyText)
-			page.SetActions(list.Actions...)
-			page.SetShowDetail(list.ShowDetail)
+	// the cached items are kept until the fresh ones are all loaded
+	if c.revalidating {
+		if !msg.done {
+			cmds = append(cmds, msg.Next())
+			return tea.Batch(cmds...)
+		}
+
+		c.revalidating = false
+		c.nextCursor = ""
+		c.emptyText = ""
+		if c.streamHeader != nil {
+			c.setListHeader(page, *c.streamHeader)
+		}
+		page.ReplaceItems(c.streamed...)
+	} else {
+		if msg.reset {
+			page.SetItems(msg.items...)
 			if c.command.Mode == sunbeam.CommandModeSearch {
-				page.OnQueryChange = func(query string) tea.Cmd {
-					c.input.Query = query
-					return c.Reload()
-				}
+				page.ResetSelection()
 			}
 
-			return page
-		default:
-			return fmt.Errorf("invalid view type")
+			c.emptyText = ""
+			c.nextCursor = ""
+		} else {
+			page.AddItems(msg.items...)
 		}
-	})
+
+		if msg.header != nil {
+			c.nextCursor = msg.header.NextCursor
+			if msg.reset {
+				c.setListHeader(page, *msg.header)
+			}
+		}
+	}
+
+	if !msg.done {
+		cmds = append(cmds, msg.Next())
+		return tea.Batch(cmds...)
+	}
+
+	if c.collecting {
+		c.collecting = false
+		cmds = append(cmds, c.loaded())
+		c.writeListCache()
+	}
+
+	page.SetEmptyText(c.emptyText)
+	cmds = append(cmds, page.SetIsLoading(false), c.finishReloadStep(nil))
+	c.streaming = false
+
+	// keep loading pages until the screen is filled
+	if page.NearEnd() {
+		cmds = append(cmds, c.LoadNextPage())
+	}
+
+	return tea.Batch(cmds...)
 }
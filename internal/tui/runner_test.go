@@ -0,0 +1,122 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pomdtr/sunbeam/internal/extensions"
+	"github.com/pomdtr/sunbeam/pkg/sunbeam"
+)
+
+func TestRunnerShowsHttpErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = io.WriteString(w, `{"title": "Test", "commands": [{"name": "list", "title": "List", "mode": "filter"}]}`)
+			return
+		}
+
+		http.Error(w, "database is down", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	extension, err := extensions.LoadExtension(server.URL)
+	if err != nil {
+		t.Fatalf("failed to load extension: %v", err)
+	}
+
+	runner := NewRunner(extension, sunbeam.Payload{Command: "list"})
+	msg := runner.streamList(context.Background(), runner.input, true)()
+
+	_, cmd := runner.Update(msg)
+	if cmd == nil {
+		t.Fatal("expected the error to be reported")
+	}
+	runner.Update(cmd())
+
+	page, ok := runner.embed.(*Detail)
+	if !ok || page.err == nil {
+		t.Fatalf("expected an error page, got %T", runner.embed)
+	}
+
+	for _, expected := range []string{"503", "database is down"} {
+		if !strings.Contains(page.err.Error(), expected) {
+			t.Errorf("expected %q in the error, got %q", expected, page.err)
+		}
+	}
+}
+
+func TestRunnerTicksOnlyWithRefreshInterval(t *testing.T) {
+	runner := NewRunner(extensions.Extension{}, sunbeam.Payload{Command: "list"})
+	if cmd := runner.tick(); cmd != nil {
+		t.Error("expected no tick without a refresh interval")
+	}
+
+	runner.refreshInterval = time.Minute
+	if cmd := runner.tick(); cmd == nil {
+		t.Error("expected a tick with a refresh interval")
+	}
+}
+
+func TestRunnerKeepsPageWhenRefreshFails(t *testing.T) {
+	runner := NewRunner(extensions.Extension{}, sunbeam.Payload{Command: "list"})
+	list := NewList(sunbeam.ListItem{Title: "Item"})
+	runner.embed = list
+	runner.refreshInterval = time.Minute
+	runner.refreshing = true
+
+	runner.Update(errors.New("network is down"))
+	if runner.embed != list {
+		t.Fatalf("expected the loaded page to be kept, got %T", runner.embed)
+	}
+
+	if runner.refreshInterval != time.Minute {
+		t.Error("expected the page to keep refreshing")
+	}
+
+	if !strings.Contains(list.statusBar.info, "network is down") {
+		t.Errorf("expected the error in the status bar, got %q", list.statusBar.info)
+	}
+}
+
+func TestRunnerIgnoresCacheWriteErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodGet {
+			_, _ = io.WriteString(w, `{"title": "Test", "commands": [{"name": "show", "title": "Show", "mode": "detail", "cache": {"ttl": 60}}]}`)
+			return
+		}
+
+		_, _ = io.WriteString(w, `{"text": "loaded"}`)
+	}))
+	defer server.Close()
+
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	extension, err := extensions.LoadExtension(server.URL)
+	if err != nil {
+		t.Fatalf("failed to load extension: %v", err)
+	}
+
+	// the cache directory can not be created under a regular file
+	extension.Dir = filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(extension.Dir, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	runner := NewRunner(extension, sunbeam.Payload{Command: "show"})
+	msg := runner.load(false)()
+	if loaded, ok := msg.(detailLoadedMsg); !ok || loaded.detail.Text != "loaded" {
+		t.Fatalf("expected the detail to be loaded, got %v", msg)
+	}
+}
@@ -0,0 +1,95 @@
+package tui
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/pomdtr/sunbeam/pkg/sunbeam"
+)
+
+// A sequence runs its steps one after the other. Each step is dispatched as a
+// regular action, and reports its completion with a sequenceStepDoneMsg once
+// its effects are over: the process exited, the page reloaded or the form
+// was submitted. The next step is only started on this message, and the
+// remaining steps are skipped if the step failed. Canceling the confirmation
+// or the form of a step stops the sequence.
+type sequenceStep struct {
+	action sunbeam.Action
+	index  int
+	// the step running the sequence, if it is nested in another one
+	parent *sequenceStep
+}
+
+// stepActionMsg asks the current page to run an action as a step of a sequence.
+type stepActionMsg struct {
+	action sunbeam.Action
+	step   *sequenceStep
+}
+
+type sequenceStepDoneMsg struct {
+	step *sequenceStep
+	err  error
+}
+
+func StartSequenceCmd(action sunbeam.Action) tea.Cmd {
+	return startSequence(action, nil)
+}
+
+func startSequence(action sunbeam.Action, parent *sequenceStep) tea.Cmd {
+	return dispatchStep(&sequenceStep{action: action, parent: parent})
+}
+
+// dispatchStep runs the step, or completes the parent step once all the steps
+// of a nested sequence are done.
+func dispatchStep(step *sequenceStep) tea.Cmd {
+	steps := step.action.Sequence.Actions
+	if step.index >= len(steps) {
+		if step.parent != nil {
+			return stepDoneCmd(step.parent, nil)
+		}
+
+		return nil
+	}
+
+	return dispatchActionMsg(steps[step.index], step)
+}
+
+func stepDoneCmd(step *sequenceStep, err error) tea.Cmd {
+	return func() tea.Msg {
+		return sequenceStepDoneMsg{step: step, err: err}
+	}
+}
+
+// next starts the step following the completed one, or shows which step failed.
+func (msg sequenceStepDoneMsg) next() tea.Cmd {
+	steps := msg.step.action.Sequence.Actions
+	if msg.err != nil {
+		step := steps[msg.step.index]
+		title := step.Title
+		if title == "" {
+			title = string(step.Type)
+		}
+
+		return PushPageCmd(NewErrorPage(fmt.Errorf("step %d of %d (%s) failed: %w", msg.step.index+1, len(steps), title, msg.err)))
+	}
+
+	return dispatchStep(&sequenceStep{
+		action: msg.step.action,
+		index:  msg.step.index + 1,
+		parent: msg.step.parent,
+	})
+}
+
+// stepError returns the error of the message an action resulted in, if any.
+func stepError(msg tea.Msg) error {
+	switch msg := msg.(type) {
+	case error:
+		return msg
+	case PushPageMsg:
+		if detail, ok := msg.Page.(*Detail); ok && detail.err != nil {
+			return detail.err
+		}
+	}
+
+	return nil
+}
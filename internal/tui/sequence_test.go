@@ -0,0 +1,156 @@
+package tui
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/pomdtr/sunbeam/internal/extensions"
+	"github.com/pomdtr/sunbeam/pkg/sunbeam"
+)
+
+// drive runs the command the way the program would, feeding the messages to
+// the page. Notifications, pushed pages and printed texts are collected instead.
+func drive(page Page, cmd tea.Cmd) []tea.Msg {
+	if cmd == nil {
+		return nil
+	}
+
+	msg := cmd()
+	if msg == nil {
+		return nil
+	}
+
+	// batches and sequences are both slices of commands
+	if value := reflect.ValueOf(msg); value.Kind() == reflect.Slice && value.Type().Elem() == reflect.TypeOf(tea.Cmd(nil)) {
+		var msgs []tea.Msg
+		for i := 0; i < value.Len(); i++ {
+			msgs = append(msgs, drive(page, value.Index(i).Interface().(tea.Cmd))...)
+		}
+		return msgs
+	}
+
+	switch msg.(type) {
+	case ShowNotificationMsg, PushPageMsg, PrintMsg:
+		return []tea.Msg{msg}
+	}
+
+	page, cmd = page.Update(msg)
+	return drive(page, cmd)
+}
+
+func execStep(command string) sunbeam.Action {
+	return sunbeam.Action{
+		Title: command,
+		Type:  sunbeam.ActionTypeExec,
+		Exec:  &sunbeam.ExecAction{Command: command},
+	}
+}
+
+func TestSequenceStopsAtFailedStep(t *testing.T) {
+	runner := NewStaticRunner(extensions.Extension{}, sunbeam.Payload{}, NewDetail(""))
+	msgs := drive(runner, StartSequenceCmd(sunbeam.Action{
+		Type: sunbeam.ActionTypeSequence,
+		Sequence: &sunbeam.SequenceAction{Actions: []sunbeam.Action{
+			execStep("echo one"),
+			execStep("exit 3"),
+			execStep("echo three"),
+		}},
+	}))
+
+	if len(msgs) != 2 {
+		t.Fatalf("expected a notification and an error page, got %v", msgs)
+	}
+
+	if notification, ok := msgs[0].(ShowNotificationMsg); !ok || notification.Title != "one" {
+		t.Errorf("expected the output of the first step, got %v", msgs[0])
+	}
+
+	push, ok := msgs[1].(PushPageMsg)
+	if !ok {
+		t.Fatalf("expected an error page, got %v", msgs[1])
+	}
+
+	page, ok := push.Page.(*Detail)
+	if !ok || page.err == nil || !strings.Contains(page.err.Error(), "step 2 of 3 (exit 3) failed") {
+		t.Fatalf("expected the failed step in the error, got %v", push.Page)
+	}
+}
+
+func TestSequenceConfirmsSteps(t *testing.T) {
+	step := execStep("echo one")
+	step.Confirm = &sunbeam.Confirm{Enabled: true}
+	sequence := sunbeam.Action{
+		Type:     sunbeam.ActionTypeSequence,
+		Sequence: &sunbeam.SequenceAction{Actions: []sunbeam.Action{step}},
+	}
+
+	push, ok := StartSequenceCmd(sequence)().(PushPageMsg)
+	if !ok {
+		t.Fatal("expected a confirmation page")
+	}
+
+	confirm, ok := push.Page.(*Confirm)
+	if !ok || confirm.step == nil {
+		t.Fatalf("expected a confirmation of the step, got %T", push.Page)
+	}
+
+	msgs := drive(NewStaticRunner(extensions.Extension{}, sunbeam.Payload{}, NewDetail("")), func() tea.Msg {
+		return actionMsg(confirm.action, confirm.step)
+	})
+	if len(msgs) != 1 {
+		t.Fatalf("expected the step to run once confirmed, got %v", msgs)
+	}
+}
+
+func TestSequenceWaitsForReload(t *testing.T) {
+	runner := NewStaticRunner(extensions.Extension{}, sunbeam.Payload{}, NewDetail(""))
+	runner.static = false
+	runner.command = sunbeam.CommandSpec{Mode: sunbeam.CommandModeDetail}
+
+	step := &sequenceStep{action: sunbeam.Action{
+		Type: sunbeam.ActionTypeSequence,
+		Sequence: &sunbeam.SequenceAction{Actions: []sunbeam.Action{
+			{Type: sunbeam.ActionTypeReload, Reload: &sunbeam.ReloadAction{}},
+		}},
+	}}
+
+	runner.reload(nil, step)
+	if runner.reloadStep != step {
+		t.Fatal("expected the step to wait for the reload")
+	}
+
+	_, cmd := runner.Update(detailLoadedMsg{detail: sunbeam.Detail{Text: "loaded"}})
+	if cmd == nil {
+		t.Fatal("expected the step to complete")
+	}
+
+	if runner.reloadStep != nil {
+		t.Error("expected the step to be completed once")
+	}
+}
+
+func TestSequenceCompletesCopyStepsInInsertMode(t *testing.T) {
+	runner := NewStaticRunner(extensions.Extension{}, sunbeam.Payload{}, NewDetail(""))
+	runner.SetInsert(true)
+	msgs := drive(runner, StartSequenceCmd(sunbeam.Action{
+		Type: sunbeam.ActionTypeSequence,
+		Sequence: &sunbeam.SequenceAction{Actions: []sunbeam.Action{
+			{Type: sunbeam.ActionTypeCopy, Copy: &sunbeam.CopyAction{Text: "one"}},
+			execStep("echo two"),
+		}},
+	}))
+
+	if len(msgs) != 2 {
+		t.Fatalf("expected the copied text and the output of the next step, got %v", msgs)
+	}
+
+	if print, ok := msgs[0].(PrintMsg); !ok || print.Text != "one" {
+		t.Errorf("expected the copied text to be printed, got %v", msgs[0])
+	}
+
+	if notification, ok := msgs[1].(ShowNotificationMsg); !ok || notification.Title != "two" {
+		t.Errorf("expected the next step to run, got %v", msgs[1])
+	}
+}
@@ -21,6 +21,7 @@ type StatusBar struct {
 	Width int
 
 	notification string
+	info         string
 
 	cursor   int
 	actions  []sunbeam.Action
@@ -48,6 +49,11 @@ func (c *StatusBar) SetActions(actions ...sunbeam.Action) {
 	c.filtered = actions
 }
 
+// SetInfo sets a faint label, displayed when there is no notification.
+func (c *StatusBar) SetInfo(info string) {
+	c.info = info
+}
+
 func (c *StatusBar) FilterActions(query string) {
 	if query == "" {
 		c.filtered = c.actions
@@ -103,18 +109,13 @@ func (p StatusBar) Update(msg tea.Msg) (StatusBar, tea.Cmd) {
 				return p, nil
 			}
 
-			action := p.filtered[p.cursor]
-			return p, func() tea.Msg {
-				return action
-			}
+			return p, DispatchAction(p.filtered[p.cursor])
 		case "alt+enter":
 			if p.cursor != 0 || len(p.actions) < 2 {
 				break
 			}
 
-			return p, func() tea.Msg {
-				return p.actions[1]
-			}
+			return p, DispatchAction(p.actions[1])
 		case "ctrl+d":
 			if p.expanded {
 				break
@@ -124,9 +125,7 @@ func (p StatusBar) Update(msg tea.Msg) (StatusBar, tea.Cmd) {
 		default:
 			for _, action := range p.actions {
 				if fmt.Sprintf("alt+%s", action.Key) == msg.String() {
-					return p, func() tea.Msg {
-						return action
-					}
+					return p, DispatchAction(action)
 				}
 			}
 		}
@@ -178,9 +177,15 @@ func ActionTitle(action sunbeam.Action) string {
 }
 
 func (c StatusBar) View() string {
+	status := c.notification
+	if status == "" {
+		status = c.info
+	}
+
 	var accessory string
 	if len(c.actions) == 0 {
-		return lipgloss.JoinVertical(lipgloss.Left, separator(c.Width), strings.Repeat(" ", c.Width))
+		blanks := strings.Repeat(" ", max(c.Width-lipgloss.Width(status)-3, 0))
+		return lipgloss.JoinVertical(lipgloss.Left, separator(c.Width), fmt.Sprintf("   %s%s", lipgloss.NewStyle().Faint(true).Render(status), blanks))
 	}
 	if c.expanded {
 		accessories := make([]string, len(c.filtered))
@@ -229,8 +234,8 @@ func (c StatusBar) View() string {
 		statusbar = fmt.Sprintf("   %s ", accessory)
 	} else {
 
-		blanks := strings.Repeat(" ", max(c.Width-lipgloss.Width(accessory)-lipgloss.Width(c.notification)-4, 0))
-		statusbar = fmt.Sprintf("   %s%s%s ", lipgloss.NewStyle().Faint(true).Render(c.notification), blanks, accessory)
+		blanks := strings.Repeat(" ", max(c.Width-lipgloss.Width(accessory)-lipgloss.Width(status)-4, 0))
+		statusbar = fmt.Sprintf("   %s%s%s ", lipgloss.NewStyle().Faint(true).Render(status), blanks, accessory)
 	}
 
 	return lipgloss.JoinVertical(lipgloss.Left, separator(c.Width), statusbar)
@@ -0,0 +1,267 @@
+package tui
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"time"
+
+	"github.com/acarl005/stripansi"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/pomdtr/sunbeam/internal/extensions"
+	"github.com/pomdtr/sunbeam/internal/schemas"
+	"github.com/pomdtr/sunbeam/pkg/sunbeam"
+)
+
+// A list command can either print a single list document, or stream
+// newline-delimited json. In streaming mode, the first line may be a list
+// header (a list document without a title), every other line is a list item.
+type listChunk struct {
+	header *sunbeam.List
+	item   *sunbeam.ListItem
+	err    error
+}
+
+type ListStreamMsg struct {
+	ctx    context.Context
+	stream <-chan listChunk
+
+	reset  bool
+	done   bool
+	header *sunbeam.List
+	items  []sunbeam.ListItem
+	err    error
+}
+
+// streamList runs the command of the extension and returns the first batch
+// of items. If reset is set, the batch replaces the current items instead of
+// being appended to them. The command is stopped if it does not complete
+// before its timeout.
+func streamList(ctx context.Context, extension extensions.Extension, input sunbeam.Payload, reset bool) tea.Msg {
+	timeout := extension.Timeout(input.Command)
+	cmdCtx, cancel := extensions.WithTimeout(ctx, timeout)
+	cmd, err := extension.CmdContext(cmdCtx, input)
+	if err != nil {
+		cancel()
+		return err
+	}
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		cancel()
+		return err
+	}
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	start := time.Now()
+	if err := cmd.Start(); err != nil {
+		cancel()
+		return err
+	}
+
+	return startListStream(ctx, reset, func(send func(listChunk) bool) error {
+		defer cancel()
+
+		readErr := readList(stdout, send)
+		if readErr != nil {
+			_, _ = io.Copy(io.Discard, stdout)
+		}
+
+		err := cmd.Wait()
+		if errors.Is(cmdCtx.Err(), context.DeadlineExceeded) {
+			err = extensions.TimeoutError{Timeout: timeout}
+		} else if err == nil {
+			err = readErr
+		}
+		extension.Log(input, start, stderr.String(), err)
+
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return fmt.Errorf("command failed: %s", stripansi.Strip(stderr.String()))
+		}
+
+		return err
+	})
+}
+
+// streamListOutput parses the output of a command that already completed.
+func streamListOutput(ctx context.Context, output func() ([]byte, error), reset bool) tea.Msg {
+	return startListStream(ctx, reset, func(send func(listChunk) bool) error {
+		bts, err := output()
+		if err != nil {
+			return err
+		}
+
+		return readList(bytes.NewReader(bts), send)
+	})
+}
+
+func startListStream(ctx context.Context, reset bool, produce func(send func(listChunk) bool) error) tea.Msg {
+	stream := make(chan listChunk)
+
+	go func() {
+		defer close(stream)
+
+		send := func(chunk listChunk) bool {
+			select {
+			case stream <- chunk:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		if err := produce(send); err != nil && ctx.Err() == nil {
+			send(listChunk{err: err})
+		}
+	}()
+
+	return waitForListChunk(ctx, stream, reset)()
+}
+
+func readList(r io.Reader, send func(listChunk) bool) error {
+	reader := bufio.NewReader(r)
+
+	var lineNumber int
+	var line []byte
+	for {
+		lineNumber++
+		bts, err := reader.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return err
+		}
+
+		line = bytes.TrimSpace(bts)
+		if len(line) > 0 || err == io.EOF {
+			break
+		}
+	}
+
+	// the output is a single list document
+	if !json.Valid(line) {
+		rest, err := io.ReadAll(reader)
+		if err != nil {
+			return err
+		}
+
+		output := append(line, rest...)
+		if err := schemas.ValidateList(output); err != nil {
+			return err
+		}
+
+		var list sunbeam.List
+		if err := json.Unmarshal(output, &list); err != nil {
+			return err
+		}
+
+		send(listChunk{header: &list})
+		return nil
+	}
+
+	var probe map[string]json.RawMessage
+	if err := json.Unmarshal(line, &probe); err != nil {
+		return fmt.Errorf("line %d: %w", lineNumber, err)
+	}
+
+	if _, ok := probe["title"]; !ok {
+		if err := schemas.ValidateList(line); err != nil {
+			return fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+
+		var header sunbeam.List
+		if err := json.Unmarshal(line, &header); err != nil {
+			return fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+
+		if !send(listChunk{header: &header}) {
+			return nil
+		}
+	} else if ok, err := sendListItem(line, lineNumber, send); err != nil || !ok {
+		return err
+	}
+
+	for {
+		lineNumber++
+		bts, err := reader.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return err
+		}
+
+		if line := bytes.TrimSpace(bts); len(line) > 0 {
+			// stop reading once the stream is canceled
+			if ok, err := sendListItem(line, lineNumber, send); err != nil || !ok {
+				return err
+			}
+		}
+
+		if err == io.EOF {
+			return nil
+		}
+	}
+}
+
+// sendListItem reports whether the item was sent, it is not if the stream
+// was canceled.
+func sendListItem(line []byte, lineNumber int, send func(listChunk) bool) (bool, error) {
+	if err := schemas.ValidateListItem(line); err != nil {
+		return false, fmt.Errorf("line %d: %w", lineNumber, err)
+	}
+
+	var item sunbeam.ListItem
+	if err := json.Unmarshal(line, &item); err != nil {
+		return false, fmt.Errorf("line %d: %w", lineNumber, err)
+	}
+
+	return send(listChunk{item: &item}), nil
+}
+
+// waitForListChunk blocks until the next chunk is available, then drains
+// the chunks that are already pending so that the list is updated in batches.
+func waitForListChunk(ctx context.Context, stream <-chan listChunk, reset bool) tea.Cmd {
+	return func() tea.Msg {
+		msg := ListStreamMsg{
+			ctx:    ctx,
+			stream: stream,
+			reset:  reset,
+		}
+
+		chunk, ok := <-stream
+		for {
+			if !ok {
+				msg.done = true
+				return msg
+			}
+
+			if chunk.err != nil {
+				msg.err = chunk.err
+				return msg
+			}
+
+			if chunk.header != nil {
+				msg.header = chunk.header
+				msg.items = append(msg.items, chunk.header.Items...)
+			}
+
+			if chunk.item != nil {
+				msg.items = append(msg.items, *chunk.item)
+			}
+
+			select {
+			case chunk, ok = <-stream:
+			default:
+				return msg
+			}
+		}
+	}
+}
+
+func (msg ListStreamMsg) Next() tea.Cmd {
+	return waitForListChunk(msg.ctx, msg.stream, false)
+}
@@ -0,0 +1,142 @@
+package tui
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/pomdtr/sunbeam/pkg/sunbeam"
+)
+
+func TestReadListStopsWhenCanceled(t *testing.T) {
+	var lines []string
+	for i := 0; i < 10; i++ {
+		lines = append(lines, `{"title": "item"}`)
+	}
+
+	var sent int
+	send := func(chunk listChunk) bool {
+		sent++
+		return sent < 3
+	}
+
+	if err := readList(strings.NewReader(strings.Join(lines, "\n")), send); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if sent != 3 {
+		t.Errorf("expected reading to stop after 3 items, got %d", sent)
+	}
+}
+
+func TestReadListPages(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		output     string
+		header     bool
+		nextCursor string
+		items      int
+	}{
+		{
+			name:       "document",
+			output:     `{"items": [{"title": "a"}, {"title": "b"}], "nextCursor": "2"}`,
+			header:     true,
+			nextCursor: "2",
+			items:      2,
+		},
+		{
+			name:       "stream with header",
+			output:     "{\"nextCursor\": \"2\"}\n{\"title\": \"a\"}\n{\"title\": \"b\"}\n",
+			header:     true,
+			nextCursor: "2",
+			items:      2,
+		},
+		{
+			name:   "stream without header",
+			output: "{\"title\": \"a\"}\n\n{\"title\": \"b\"}",
+			items:  2,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var header *sunbeam.List
+			var items int
+			err := readList(strings.NewReader(tc.output), func(chunk listChunk) bool {
+				if chunk.header != nil {
+					header = chunk.header
+					items += len(chunk.header.Items)
+				}
+				if chunk.item != nil {
+					items++
+				}
+				return true
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if (header != nil) != tc.header {
+				t.Fatalf("expected header: %v, got %v", tc.header, header)
+			}
+
+			if header != nil && header.NextCursor != tc.nextCursor {
+				t.Errorf("expected next cursor %q, got %q", tc.nextCursor, header.NextCursor)
+			}
+
+			if items != tc.items {
+				t.Errorf("expected %d items, got %d", tc.items, items)
+			}
+		})
+	}
+}
+
+func TestNextPageCursor(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		header     *sunbeam.List
+		nextCursor string
+	}{
+		{
+			name:       "page with header",
+			header:     &sunbeam.List{NextCursor: "3"},
+			nextCursor: "3",
+		},
+		{
+			name:   "page without header",
+			header: nil,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			runner := &Runner{
+				embed:      NewList(sunbeam.ListItem{Title: "a"}),
+				nextCursor: "2",
+			}
+
+			if cmd := runner.LoadNextPage(); cmd == nil {
+				t.Fatal("expected the next page to be loaded")
+			}
+
+			if runner.nextCursor != "" {
+				t.Errorf("expected the cursor to be cleared while loading, got %q", runner.nextCursor)
+			}
+
+			runner.handleListStream(ListStreamMsg{
+				ctx:    context.Background(),
+				header: tc.header,
+				items:  []sunbeam.ListItem{{Title: "b"}},
+				done:   true,
+			})
+
+			if runner.nextCursor != tc.nextCursor {
+				t.Errorf("expected next cursor %q, got %q", tc.nextCursor, runner.nextCursor)
+			}
+
+			if runner.streaming {
+				t.Error("expected the stream to be done")
+			}
+
+			if tc.nextCursor == "" && runner.LoadNextPage() != nil {
+				t.Error("expected no more pages to be loaded")
+			}
+		})
+	}
+}
@@ -20,3 +20,11 @@ func CacheDir() string {
 
 	return filepath.Join(os.Getenv("HOME"), ".cache", "sunbeam")
 }
+
+func DataDir() string {
+	if env, ok := os.LookupEnv("XDG_DATA_HOME"); ok {
+		return filepath.Join(env, "sunbeam")
+	}
+
+	return filepath.Join(os.Getenv("HOME"), ".local", "share", "sunbeam")
+}
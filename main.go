@@ -5,6 +5,7 @@ import (
 	"os"
 
 	"github.com/pomdtr/sunbeam/internal/cli"
+	"github.com/pomdtr/sunbeam/internal/extensions"
 )
 
 func main() {
@@ -14,7 +15,9 @@ func main() {
 		os.Exit(1)
 	}
 
-	if err := rootCmd.Execute(); err != nil {
+	err = rootCmd.Execute()
+	extensions.StopAll()
+	if err != nil {
 		os.Exit(1)
 	}
 }
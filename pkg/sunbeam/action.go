@@ -2,27 +2,34 @@ package sunbeam
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 )
 
 type Action struct {
-	Title string     `json:"title,omitempty"`
-	Key   string     `json:"key,omitempty"`
-	Type  ActionType `json:"type,omitempty"`
+	Title   string     `json:"title,omitempty"`
+	Key     string     `json:"key,omitempty"`
+	Type    ActionType `json:"type,omitempty"`
+	Confirm *Confirm   `json:"confirm,omitempty"`
 
-	Open   *OpenAction   `json:"-"`
-	Copy   *CopyAction   `json:"-"`
-	Run    *RunAction    `json:"-"`
-	Exec   *ExecAction   `json:"-"`
-	Edit   *EditAction   `json:"-"`
-	Config *ConfigAction `json:"-"`
-	Reload *ReloadAction `json:"-"`
+	Open     *OpenAction     `json:"-"`
+	Copy     *CopyAction     `json:"-"`
+	Run      *RunAction      `json:"-"`
+	Exec     *ExecAction     `json:"-"`
+	Edit     *EditAction     `json:"-"`
+	Config   *ConfigAction   `json:"-"`
+	Reload   *ReloadAction   `json:"-"`
+	Push     *PushAction     `json:"-"`
+	Sequence *SequenceAction `json:"-"`
+	Print    *PrintAction    `json:"-"`
 }
 
 func (a *Action) UnmarshalJSON(bts []byte) error {
 	var action struct {
-		Title string `json:"title,omitempty"`
-		Key   string `json:"key,omitempty"`
-		Type  string `json:"type,omitempty"`
+		Title   string   `json:"title,omitempty"`
+		Key     string   `json:"key,omitempty"`
+		Type    string   `json:"type,omitempty"`
+		Confirm *Confirm `json:"confirm,omitempty"`
 	}
 
 	if err := json.Unmarshal(bts, &action); err != nil {
@@ -32,6 +39,7 @@ func (a *Action) UnmarshalJSON(bts []byte) error {
 	a.Title = action.Title
 	a.Key = action.Key
 	a.Type = ActionType(action.Type)
+	a.Confirm = action.Confirm
 
 	switch a.Type {
 	case ActionTypeRun:
@@ -57,11 +65,112 @@ func (a *Action) UnmarshalJSON(bts []byte) error {
 	case ActionTypeConfig:
 		a.Config = &ConfigAction{}
 		return json.Unmarshal(bts, a.Config)
+	case ActionTypePush:
+		a.Push = &PushAction{}
+		return json.Unmarshal(bts, a.Push)
+	case ActionTypeSequence:
+		a.Sequence = &SequenceAction{}
+		return json.Unmarshal(bts, a.Sequence)
+	case ActionTypePrint:
+		a.Print = &PrintAction{}
+		return json.Unmarshal(bts, a.Print)
 	}
 
 	return nil
 }
 
+func (a Action) MarshalJSON() ([]byte, error) {
+	var payload any
+	switch a.Type {
+	case ActionTypeRun:
+		payload = a.Run
+	case ActionTypeOpen:
+		payload = a.Open
+	case ActionTypeCopy:
+		payload = a.Copy
+	case ActionTypeEdit:
+		payload = a.Edit
+	case ActionTypeExec:
+		payload = a.Exec
+	case ActionTypeReload:
+		payload = a.Reload
+	case ActionTypeConfig:
+		payload = a.Config
+	case ActionTypePush:
+		payload = a.Push
+	case ActionTypeSequence:
+		payload = a.Sequence
+	case ActionTypePrint:
+		payload = a.Print
+	}
+
+	// the fields of the payload are merged with the common ones, without
+	// decoding their values, so that numbers keep their precision
+	fields := make(map[string]json.RawMessage)
+	if payload != nil {
+		bts, err := json.Marshal(payload)
+		if err != nil {
+			return nil, err
+		}
+
+		// a nil payload is encoded as null, which leaves the fields empty
+		if err := json.Unmarshal(bts, &fields); err != nil {
+			return nil, fmt.Errorf("invalid %s action: %w", a.Type, err)
+		}
+	}
+
+	common, err := json.Marshal(struct {
+		Title   string     `json:"title,omitempty"`
+		Key     string     `json:"key,omitempty"`
+		Type    ActionType `json:"type,omitempty"`
+		Confirm *Confirm   `json:"confirm,omitempty"`
+	}{a.Title, a.Key, a.Type, a.Confirm})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(common, &fields); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(fields)
+}
+
+// Confirm is either a boolean, or the message to display in the confirmation dialog
+type Confirm struct {
+	Enabled bool
+	Message string
+}
+
+func (c Confirm) Required() bool {
+	return c.Enabled || c.Message != ""
+}
+
+func (c *Confirm) UnmarshalJSON(bts []byte) error {
+	var enabled bool
+	if err := json.Unmarshal(bts, &enabled); err == nil {
+		c.Enabled = enabled
+		return nil
+	}
+
+	var message string
+	if err := json.Unmarshal(bts, &message); err != nil {
+		return errors.New("confirm must be a boolean or a string")
+	}
+
+	c.Enabled = true
+	c.Message = message
+	return nil
+}
+
+func (c Confirm) MarshalJSON() ([]byte, error) {
+	if c.Message != "" {
+		return json.Marshal(c.Message)
+	}
+
+	return json.Marshal(c.Enabled)
+}
+
 type ConfigAction struct {
 	Extension string `json:"extension,omitempty"`
 }
@@ -76,6 +185,55 @@ type ReloadAction struct {
 	Params map[string]any `json:"params,omitempty"`
 }
 
+// PushAction opens a list or a detail provided inline, without running a command
+type PushAction struct {
+	List   *List   `json:"list,omitempty"`
+	Detail *Detail `json:"detail,omitempty"`
+
+	// the json of the list or detail, as sent by the extension
+	rawList   json.RawMessage
+	rawDetail json.RawMessage
+}
+
+func (p *PushAction) UnmarshalJSON(bts []byte) error {
+	var raw struct {
+		List   json.RawMessage `json:"list"`
+		Detail json.RawMessage `json:"detail"`
+	}
+
+	if err := json.Unmarshal(bts, &raw); err != nil {
+		return err
+	}
+
+	type pushAction PushAction
+	var push pushAction
+	if err := json.Unmarshal(bts, &push); err != nil {
+		return err
+	}
+
+	*p = PushAction(push)
+	p.rawList = raw.List
+	p.rawDetail = raw.Detail
+	return nil
+}
+
+// RawList returns the json the list was decoded from, or nil if the action
+// was not decoded.
+func (p PushAction) RawList() json.RawMessage {
+	return p.rawList
+}
+
+// RawDetail returns the json the detail was decoded from, or nil if the
+// action was not decoded.
+func (p PushAction) RawDetail() json.RawMessage {
+	return p.rawDetail
+}
+
+// SequenceAction runs its actions one after another, stopping at the first failure
+type SequenceAction struct {
+	Actions []Action `json:"actions"`
+}
+
 type RunAction struct {
 	Extension string         `json:"extension,omitempty"`
 	Command   string         `json:"command,omitempty"`
@@ -84,6 +242,11 @@ type RunAction struct {
 	Exit      bool           `json:"exit,omitempty"`
 }
 
+// PrintAction writes the text to stdout, once sunbeam exited
+type PrintAction struct {
+	Text string `json:"text,omitempty"`
+}
+
 type CopyAction struct {
 	Text string `json:"text,omitempty"`
 	Exit bool   `json:"exit,omitempty"`
@@ -94,6 +257,9 @@ type ExecAction struct {
 	Command     string `json:"command,omitempty"`
 	Dir         string `json:"dir,omitempty"`
 	Exit        bool   `json:"exit,omitempty"`
+	// Env values can reference the preferences and the current query, ex: "$token"
+	Env   map[string]string `json:"env,omitempty"`
+	Stdin string            `json:"stdin,omitempty"`
 }
 
 type OpenAction struct {
@@ -104,14 +270,17 @@ type OpenAction struct {
 type ActionType string
 
 const (
-	ActionTypeRun    ActionType = "run"
-	ActionTypeOpen   ActionType = "open"
-	ActionTypeCopy   ActionType = "copy"
-	ActionTypeEdit   ActionType = "edit"
-	ActionTypeExec   ActionType = "exec"
-	ActionTypeExit   ActionType = "exit"
-	ActionTypeReload ActionType = "reload"
-	ActionTypeConfig ActionType = "config"
+	ActionTypeRun      ActionType = "run"
+	ActionTypeOpen     ActionType = "open"
+	ActionTypeCopy     ActionType = "copy"
+	ActionTypeEdit     ActionType = "edit"
+	ActionTypeExec     ActionType = "exec"
+	ActionTypeExit     ActionType = "exit"
+	ActionTypeReload   ActionType = "reload"
+	ActionTypeConfig   ActionType = "config"
+	ActionTypePush     ActionType = "push"
+	ActionTypeSequence ActionType = "sequence"
+	ActionTypePrint    ActionType = "print"
 )
 
 type Payload struct {
@@ -120,4 +289,6 @@ type Payload struct {
 	Params      map[string]any `json:"params"`
 	Cwd         string         `json:"cwd"`
 	Query       string         `json:"query,omitempty"`
+	Cursor      string         `json:"cursor,omitempty"`
+	DataDir     string         `json:"dataDir,omitempty"`
 }
@@ -0,0 +1,78 @@
+package sunbeam
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestActionRoundTrip(t *testing.T) {
+	for _, action := range []Action{
+		{Title: "Run", Key: "r", Type: ActionTypeRun, Run: &RunAction{Extension: "github", Command: "list", Params: map[string]any{"repo": "sunbeam", "draft": true}, Reload: true}},
+		{Title: "Open", Type: ActionTypeOpen, Open: &OpenAction{Url: "https://example.com?a=1&b=2"}},
+		{Title: "Copy", Type: ActionTypeCopy, Copy: &CopyAction{Text: "<hello>", Exit: true}},
+		{Title: "Edit", Type: ActionTypeEdit, Edit: &EditAction{Path: "~/notes.md", Reload: true}},
+		{Title: "Exec", Type: ActionTypeExec, Confirm: &Confirm{Enabled: true, Message: "Delete the repository?"}, Exec: &ExecAction{Command: "rm -rf $dir", Interactive: true, Env: map[string]string{"TOKEN": "$token"}, Stdin: "$query"}},
+		{Title: "Exit", Type: ActionTypeExit},
+		{Title: "Reload", Type: ActionTypeReload, Confirm: &Confirm{Enabled: true}, Reload: &ReloadAction{Params: map[string]any{"page": "2"}}},
+		{Title: "Config", Type: ActionTypeConfig, Config: &ConfigAction{Extension: "github"}},
+		{Title: "Push", Type: ActionTypePush, Push: &PushAction{List: &List{Items: []ListItem{{Title: "Item", Detail: ListItemDetail{Markdown: "# Item"}, Actions: []Action{{Title: "Copy", Type: ActionTypeCopy, Copy: &CopyAction{Text: "item"}}}}}}}},
+		{Title: "Push Detail", Type: ActionTypePush, Push: &PushAction{Detail: &Detail{Text: "hello", Metadata: []MetadataItem{{Type: MetadataItemLink, Title: "Home", Url: "https://example.com"}}}}},
+		{Title: "Sequence", Type: ActionTypeSequence, Sequence: &SequenceAction{Actions: []Action{
+			{Title: "Copy", Type: ActionTypeCopy, Copy: &CopyAction{Text: "a"}},
+			{Title: "Reload", Type: ActionTypeReload, Reload: &ReloadAction{}},
+		}}},
+		{Title: "Print", Type: ActionTypePrint, Print: &PrintAction{Text: "hello\nworld"}},
+	} {
+		t.Run(string(action.Type), func(t *testing.T) {
+			bts, err := json.Marshal(action)
+			if err != nil {
+				t.Fatalf("failed to marshal: %v", err)
+			}
+
+			var decoded Action
+			if err := json.Unmarshal(bts, &decoded); err != nil {
+				t.Fatalf("failed to unmarshal %s: %v", bts, err)
+			}
+
+			// the raw json is only kept to be validated
+			if decoded.Push != nil {
+				decoded.Push.rawList, decoded.Push.rawDetail = nil, nil
+			}
+
+			if !reflect.DeepEqual(action, decoded) {
+				t.Errorf("round trip mismatch:\nexpected %+v\ngot      %+v\njson     %s", action, decoded, bts)
+			}
+		})
+	}
+}
+
+func TestActionMarshalKeepsNumbers(t *testing.T) {
+	action := Action{Title: "Run", Type: ActionTypeRun, Run: &RunAction{Command: "show", Params: map[string]any{"id": int64(9007199254740993)}}}
+
+	bts, err := json.Marshal(action)
+	if err != nil {
+		t.Fatalf("failed to marshal: %v", err)
+	}
+
+	if !strings.Contains(string(bts), "9007199254740993") {
+		t.Errorf("expected the id to keep its precision, got %s", bts)
+	}
+
+	expected := `{"command":"show","params":{"id":9007199254740993},"title":"Run","type":"run"}`
+	if string(bts) != expected {
+		t.Errorf("expected %s, got %s", expected, bts)
+	}
+}
+
+func TestListItemOmitsEmptyDetail(t *testing.T) {
+	bts, err := json.Marshal(ListItem{Title: "Item"})
+	if err != nil {
+		t.Fatalf("failed to marshal: %v", err)
+	}
+
+	if string(bts) != `{"title":"Item"}` {
+		t.Errorf("unexpected output: %s", bts)
+	}
+}
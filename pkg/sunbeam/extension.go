@@ -0,0 +1,190 @@
+package sunbeam
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Extension implements the sunbeam extension protocol: the manifest is
+// printed when no arguments are provided, otherwise the payload is decoded
+// from the first argument and dispatched to the matching command handler.
+type Extension struct {
+	Manifest Manifest
+	handlers map[string]func(Payload, io.Writer) error
+}
+
+type ListHandler func(Payload) (List, error)
+type DetailHandler func(Payload) (Detail, error)
+type CommandHandler func(Payload) error
+
+func NewExtension(manifest Manifest) *Extension {
+	return &Extension{
+		Manifest: manifest,
+		handlers: make(map[string]func(Payload, io.Writer) error),
+	}
+}
+
+// HandleList registers a search or filter command. The mode defaults to filter.
+func (e *Extension) HandleList(spec CommandSpec, handler ListHandler) {
+	if spec.Mode == "" {
+		spec.Mode = CommandModeFilter
+	}
+
+	e.addCommand(spec, func(payload Payload, w io.Writer) error {
+		list, err := handler(payload)
+		if err != nil {
+			return err
+		}
+
+		return encode(w, list)
+	})
+}
+
+// HandleDetail registers a detail command.
+func (e *Extension) HandleDetail(spec CommandSpec, handler DetailHandler) {
+	spec.Mode = CommandModeDetail
+
+	e.addCommand(spec, func(payload Payload, w io.Writer) error {
+		detail, err := handler(payload)
+		if err != nil {
+			return err
+		}
+
+		return encode(w, detail)
+	})
+}
+
+// Handle registers a silent or tty command. The mode defaults to silent.
+func (e *Extension) Handle(spec CommandSpec, handler CommandHandler) {
+	if spec.Mode == "" {
+		spec.Mode = CommandModeSilent
+	}
+
+	e.addCommand(spec, func(payload Payload, w io.Writer) error {
+		return handler(payload)
+	})
+}
+
+func (e *Extension) addCommand(spec CommandSpec, handler func(Payload, io.Writer) error) {
+	for i, command := range e.Manifest.Commands {
+		if command.Name == spec.Name {
+			e.Manifest.Commands[i] = spec
+			e.handlers[spec.Name] = handler
+			return
+		}
+	}
+
+	e.Manifest.Commands = append(e.Manifest.Commands, spec)
+	e.handlers[spec.Name] = handler
+}
+
+// Run executes the extension using the process arguments, and exits on error.
+func (e *Extension) Run() {
+	var err error
+	if os.Getenv("SUNBEAM_RPC") == "1" {
+		err = e.Serve(os.Stdin, os.Stdout)
+	} else {
+		err = e.Execute(os.Args[1:], os.Stdout)
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// Execute prints the manifest if args is empty, or runs the command
+// described by the payload in args[0].
+func (e *Extension) Execute(args []string, w io.Writer) error {
+	if len(args) == 0 {
+		return encode(w, e.Manifest)
+	}
+
+	var payload Payload
+	if err := json.Unmarshal([]byte(args[0]), &payload); err != nil {
+		return fmt.Errorf("invalid payload: %w", err)
+	}
+
+	return e.dispatch(payload, w)
+}
+
+func (e *Extension) dispatch(payload Payload, w io.Writer) error {
+	handler, ok := e.handlers[payload.Command]
+	if !ok {
+		return fmt.Errorf("command %s not found", payload.Command)
+	}
+
+	return handler(payload, w)
+}
+
+// Serve answers the json-rpc requests of persistent extensions, until r is closed.
+func (e *Extension) Serve(r io.Reader, w io.Writer) error {
+	type request struct {
+		ID     int             `json:"id"`
+		Method string          `json:"method"`
+		Params json.RawMessage `json:"params"`
+	}
+
+	type rpcError struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	}
+
+	type response struct {
+		Version string          `json:"jsonrpc"`
+		ID      int             `json:"id"`
+		Result  json.RawMessage `json:"result,omitempty"`
+		Error   *rpcError       `json:"error,omitempty"`
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+
+	reader := bufio.NewReader(r)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) > 0 {
+			var req request
+			if err := json.Unmarshal(line, &req); err != nil {
+				return fmt.Errorf("invalid request: %w", err)
+			}
+
+			// notifications, such as cancellations, have no id
+			if req.Method == "run" && req.ID != 0 {
+				res := response{Version: "2.0", ID: req.ID}
+
+				var payload Payload
+				var output bytes.Buffer
+				if err := json.Unmarshal(req.Params, &payload); err != nil {
+					res.Error = &rpcError{Code: -32602, Message: err.Error()}
+				} else if err := e.dispatch(payload, &output); err != nil {
+					res.Error = &rpcError{Code: 1, Message: err.Error()}
+				} else if output.Len() > 0 {
+					res.Result = bytes.TrimSpace(output.Bytes())
+				}
+
+				if err := encoder.Encode(res); err != nil {
+					return err
+				}
+			}
+		}
+
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
+
+func encode(w io.Writer, v any) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+
+	return encoder.Encode(v)
+}
@@ -1,10 +1,21 @@
 package sunbeam
 
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sync"
+	"unicode/utf8"
+)
+
 type Manifest struct {
-	Title       string        `json:"title"`
-	Description string        `json:"description,omitempty"`
-	Preferences []Input       `json:"preferences,omitempty"`
-	Commands    []CommandSpec `json:"commands"`
+	Title       string            `json:"title"`
+	Description string            `json:"description,omitempty"`
+	Preferences []Input           `json:"preferences,omitempty"`
+	Commands    []CommandSpec     `json:"commands"`
+	Persistent  bool              `json:"persistent,omitempty"`
+	Headers     map[string]string `json:"headers,omitempty"`
 }
 
 type CommandSpec struct {
@@ -13,6 +24,16 @@ type CommandSpec struct {
 	Hidden bool        `json:"hidden,omitempty"`
 	Params []Input     `json:"params,omitempty"`
 	Mode   CommandMode `json:"mode,omitempty"`
+	// Timeout is the maximum duration of the command, in seconds
+	Timeout int        `json:"timeout,omitempty"`
+	Cache   *CacheSpec `json:"cache,omitempty"`
+}
+
+// CacheSpec enables the cache of a list or detail command. The cached output
+// is displayed immediately, while the command runs in the background.
+type CacheSpec struct {
+	// TTL is the maximum age of the cached output, in seconds
+	TTL int `json:"ttl,omitempty"`
 }
 
 type Platfom string
@@ -43,12 +64,126 @@ const (
 	InputString  InputType = "string"
 	InputBoolean InputType = "boolean"
 	InputNumber  InputType = "number"
+	InputSelect  InputType = "select"
+	// InputSecret is a string stored encrypted, outside of the config
+	InputSecret InputType = "secret"
 )
 
 type Input struct {
-	Type     InputType `json:"type"`
-	Name     string    `json:"name"`
-	Title    string    `json:"title"`
-	Optional bool      `json:"optional,omitempty"`
-	Default  any       `json:"default,omitempty"`
+	Type           InputType     `json:"type"`
+	Name           string        `json:"name"`
+	Title          string        `json:"title"`
+	Optional       bool          `json:"optional,omitempty"`
+	Default        any           `json:"default,omitempty"`
+	Options        []InputOption `json:"options,omitempty"`
+	OptionsCommand string        `json:"optionsCommand,omitempty"`
+	Pattern        string        `json:"pattern,omitempty"`
+	Min            *float64      `json:"min,omitempty"`
+	Max            *float64      `json:"max,omitempty"`
+	MinLength      *int          `json:"minLength,omitempty"`
+	MaxLength      *int          `json:"maxLength,omitempty"`
+	ErrorMessage   string        `json:"errorMessage,omitempty"`
+}
+
+// Validate checks the value against the constraints of the input.
+// Nil values are considered valid, required inputs are checked separately.
+func (i Input) Validate(value any) error {
+	if value == nil {
+		return nil
+	}
+
+	if err := i.validate(value); err != nil {
+		if i.ErrorMessage != "" {
+			return errors.New(i.ErrorMessage)
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+func (i Input) validate(value any) error {
+	switch i.Type {
+	case InputString, InputSelect, InputSecret:
+		s, ok := value.(string)
+		if !ok {
+			return errors.New("must be a string")
+		}
+
+		length := utf8.RuneCountInString(s)
+		if i.MinLength != nil && length < *i.MinLength {
+			return fmt.Errorf("must be at least %d characters long", *i.MinLength)
+		}
+
+		if i.MaxLength != nil && length > *i.MaxLength {
+			return fmt.Errorf("must be at most %d characters long", *i.MaxLength)
+		}
+
+		if i.Pattern != "" {
+			re, err := i.Regexp()
+			if err != nil {
+				return err
+			}
+
+			if !re.MatchString(s) {
+				return fmt.Errorf("must match the pattern %s", i.Pattern)
+			}
+		}
+	case InputNumber:
+		var n float64
+		switch v := value.(type) {
+		case int:
+			n = float64(v)
+		case int64:
+			n = float64(v)
+		case float64:
+			n = v
+		case json.Number:
+			f, err := v.Float64()
+			if err != nil {
+				return errors.New("must be a number")
+			}
+			n = f
+		default:
+			return errors.New("must be a number")
+		}
+
+		if i.Min != nil && n < *i.Min {
+			return fmt.Errorf("must be greater than or equal to %v", *i.Min)
+		}
+
+		if i.Max != nil && n > *i.Max {
+			return fmt.Errorf("must be less than or equal to %v", *i.Max)
+		}
+	case InputBoolean:
+		if _, ok := value.(bool); !ok {
+			return errors.New("must be a boolean")
+		}
+	}
+
+	return nil
+}
+
+// compiled patterns, inputs are validated on every keystroke
+var patterns sync.Map
+
+// Regexp returns the compiled pattern of the input.
+func (i Input) Regexp() (*regexp.Regexp, error) {
+	if re, ok := patterns.Load(i.Pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+
+	re, err := regexp.Compile(i.Pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %s: %w", i.Pattern, err)
+	}
+
+	patterns.Store(i.Pattern, re)
+	return re, nil
+}
+
+type InputOption struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
 }
@@ -1,28 +1,87 @@
 package sunbeam
 
+import "encoding/json"
+
 type List struct {
-	Items      []ListItem `json:"items,omitempty"`
-	EmptyText  string     `json:"emptyText,omitempty"`
-	ShowDetail bool       `json:"showDetail,omitempty"`
-	Actions    []Action   `json:"actions,omitempty"`
+	Items           []ListItem `json:"items,omitempty"`
+	EmptyText       string     `json:"emptyText,omitempty"`
+	ShowDetail      bool       `json:"showDetail,omitempty"`
+	Actions         []Action   `json:"actions,omitempty"`
+	NextCursor      string     `json:"nextCursor,omitempty"`
+	Layout          ListLayout `json:"layout,omitempty"`
+	Columns         int        `json:"columns,omitempty"`
+	RefreshInterval int        `json:"refreshInterval,omitempty"`
 }
 
+type ListLayout string
+
+const (
+	ListLayoutList ListLayout = "list"
+	ListLayoutGrid ListLayout = "grid"
+)
+
 type ListItem struct {
 	Id          string         `json:"id,omitempty"`
 	Title       string         `json:"title"`
 	Subtitle    string         `json:"subtitle,omitempty"`
 	Detail      ListItemDetail `json:"detail,omitempty"`
 	Accessories []string       `json:"accessories,omitempty"`
+	Section     string         `json:"section,omitempty"`
+	Content     string         `json:"content,omitempty"`
 	Actions     []Action       `json:"actions,omitempty"`
 }
 
+// MarshalJSON omits the detail of the item if it is empty.
+func (i ListItem) MarshalJSON() ([]byte, error) {
+	type listItem ListItem
+
+	var detail *ListItemDetail
+	if !i.Detail.IsZero() {
+		detail = &i.Detail
+	}
+
+	return json.Marshal(struct {
+		listItem
+		Detail *ListItemDetail `json:"detail,omitempty"`
+	}{listItem(i), detail})
+}
+
 type ListItemDetail struct {
-	Markdown string `json:"markdown,omitempty"`
-	Text     string `json:"text,omitempty"`
+	Markdown string         `json:"markdown,omitempty"`
+	Text     string         `json:"text,omitempty"`
+	Metadata []MetadataItem `json:"metadata,omitempty"`
+}
+
+func (d ListItemDetail) IsZero() bool {
+	return d.Markdown == "" && d.Text == "" && len(d.Metadata) == 0
 }
 
 type Detail struct {
-	Actions  []Action `json:"actions,omitempty"`
-	Markdown string   `json:"markdown,omitempty"`
-	Text     string   `json:"text,omitempty"`
+	Actions         []Action       `json:"actions,omitempty"`
+	Markdown        string         `json:"markdown,omitempty"`
+	Text            string         `json:"text,omitempty"`
+	Metadata        []MetadataItem `json:"metadata,omitempty"`
+	RefreshInterval int            `json:"refreshInterval,omitempty"`
+}
+
+type MetadataItemType string
+
+const (
+	MetadataItemLabel     MetadataItemType = "label"
+	MetadataItemLink      MetadataItemType = "link"
+	MetadataItemTags      MetadataItemType = "tags"
+	MetadataItemSeparator MetadataItemType = "separator"
+)
+
+type MetadataItem struct {
+	Type  MetadataItemType `json:"type,omitempty"`
+	Title string           `json:"title,omitempty"`
+	Text  string           `json:"text,omitempty"`
+	Url   string           `json:"url,omitempty"`
+	Tags  []MetadataTag    `json:"tags,omitempty"`
+}
+
+type MetadataTag struct {
+	Text  string `json:"text"`
+	Color string `json:"color,omitempty"`
 }
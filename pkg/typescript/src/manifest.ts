@@ -16,14 +16,30 @@ export type Command = {
 export type Input = {
   name: string;
   title: string;
-  type: "string" | "number" | "boolean";
+  type: "string" | "number" | "boolean" | "select" | "secret";
   optional?: boolean;
+  default?: string | number | boolean;
+  options?: readonly InputOption[];
+  optionsCommand?: string;
+  pattern?: string;
+  min?: number;
+  max?: number;
+  minLength?: number;
+  maxLength?: number;
+  errorMessage?: string;
+};
+
+export type InputOption = {
+  title: string;
+  value: string;
 };
 
 type InputMap = {
   string: string;
   number: number;
   boolean: boolean;
+  select: string;
+  secret: string;
 };
 
 type CommandName<M extends Manifest> = M["commands"][number]["name"];